@@ -0,0 +1,38 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ojarva/subsurface-statistics/subsurfacetypes"
+)
+
+// anonymizer replaces people's names with pseudonyms numbered by first occurrence. Names are matched
+// case-insensitively, like buddy statistics, and a person gets the same pseudonym as buddy and divemaster.
+type anonymizer map[string]string
+
+func (a anonymizer) pseudonym(name string) string {
+	name = collapseWhitespace(name)
+	if name == "" {
+		return ""
+	}
+	key := strings.ToLower(name)
+	if _, ok := a[key]; !ok {
+		a[key] = fmt.Sprintf("Buddy #%d", len(a)+1)
+	}
+	return a[key]
+}
+
+// anonymizeDives replaces buddy and divemaster names in dives with pseudonyms, in the order the dives
+// are listed.
+func anonymizeDives(dives []subsurfacetypes.Dive) {
+	a := anonymizer{}
+	for i := range dives {
+		buddies := dives[i].BuddyList()
+		for j, buddy := range buddies {
+			buddies[j] = a.pseudonym(buddy)
+		}
+		dives[i].Buddy = strings.Join(buddies, ", ")
+		dives[i].Divemaster = a.pseudonym(dives[i].Divemaster)
+	}
+}
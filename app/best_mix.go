@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/ojarva/subsurface-statistics/counter"
+	"github.com/ojarva/subsurface-statistics/subsurfacetypes"
+)
+
+// richerMixDive is a dive where a gas with more oxygen could have been used.
+type richerMixDive struct {
+	Number   string
+	Date     time.Time
+	MaxDepth float64
+	Gas      subsurfacetypes.GasMix
+	BestMix  subsurfacetypes.GasMix
+}
+
+// richerMixDives returns valid dives breathed on air or nitrox where the best mix for the max depth has
+// more oxygen than the bottom gas. Trimix dives are left out.
+func richerMixDives(dives []subsurfacetypes.Dive) []richerMixDive {
+	var result []richerMixDive
+	for i := range dives {
+		if dives[i].IsInvalid() {
+			continue
+		}
+		gas := dives[i].BottomGas()
+		bestO2, ok := dives[i].BestMixO2()
+		if !ok || gas.He > 0 || bestO2 <= gas.O2+0.005 {
+			continue
+		}
+		result = append(result, richerMixDive{dives[i].Number, dives[i].Date.Value, dives[i].Depth().Max.Value, gas, subsurfacetypes.GasMix{O2: bestO2}})
+	}
+	return result
+}
+
+func printRicherMixDives(dives []richerMixDive) {
+	t := table.NewWriter()
+	t.SetOutputMirror(output)
+	t.SetStyle(counter.TableStyle)
+	t.AppendHeader(table.Row{"Sukellus", "Päivämäärä", "Maksimisyvyys", "Kaasu", "Paras seos"})
+	t.AppendSeparator()
+	for _, dive := range dives {
+		t.AppendRow([]interface{}{dive.Number, dive.Date.Format("2006-01-02"), fmt.Sprintf("%.1f m", dive.MaxDepth), dive.Gas.String(), dive.BestMix.String()})
+	}
+	t.Render()
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/ojarva/subsurface-statistics/counter"
+	"github.com/ojarva/subsurface-statistics/subsurfacetypes"
+)
+
+// buddyPair is an unordered pair of buddies. First is always alphabetically before Second, ignoring case.
+type buddyPair struct {
+	First  string
+	Second string
+}
+
+func newBuddyPair(a, b string) buddyPair {
+	if strings.ToLower(b) < strings.ToLower(a) {
+		a, b = b, a
+	}
+	return buddyPair{a, b}
+}
+
+func (bp buddyPair) String() string {
+	return fmt.Sprintf("%s & %s", bp.First, bp.Second)
+}
+
+// buddyPairCount holds the number of dives shared by a pair of buddies.
+type buddyPairCount struct {
+	Pair  buddyPair
+	Count int
+}
+
+// countBuddyPairs counts how many valid dives each unordered pair of buddies was on together.
+// Buddies are matched like in buddy statistics and shown with the same spelling. Buddies excluded with
+// -exclude-buddy are left out. Dives with less than two distinct buddies do not contribute anything.
+func countBuddyPairs(dives []subsurfacetypes.Dive) map[buddyPair]int {
+	pairs := make(map[buddyPair]int)
+	spellings := buddySpellings(dives)
+	for i := range dives {
+		if dives[i].IsInvalid() {
+			continue
+		}
+		var buddies []string
+		for _, buddy := range countedBuddies(&dives[i]) {
+			buddies = append(buddies, spellings[buddyKey(buddy)])
+		}
+		for a := 0; a < len(buddies); a++ {
+			for b := a + 1; b < len(buddies); b++ {
+				pairs[newBuddyPair(buddies[a], buddies[b])]++
+			}
+		}
+	}
+	return pairs
+}
+
+// topBuddyPairs returns at most n most common buddy pairs, most common first.
+func topBuddyPairs(dives []subsurfacetypes.Dive, n int) []buddyPairCount {
+	pairs := countBuddyPairs(dives)
+	sl := make([]buddyPairCount, 0, len(pairs))
+	for pair, count := range pairs {
+		sl = append(sl, buddyPairCount{pair, count})
+	}
+	sort.Slice(sl, func(i, j int) bool {
+		if sl[i].Count != sl[j].Count {
+			return sl[i].Count > sl[j].Count
+		}
+		return sl[i].Pair.String() < sl[j].Pair.String()
+	})
+	if n < len(sl) {
+		sl = sl[:n]
+	}
+	return sl
+}
+
+func printBuddyPairs(pairs []buddyPairCount) {
+	t := table.NewWriter()
+	t.SetOutputMirror(output)
+	t.SetStyle(counter.TableStyle)
+	t.AppendHeader(table.Row{"#", "Pari", "Kertoja"})
+	t.AppendSeparator()
+	for i, pair := range pairs {
+		t.AppendRow([]interface{}{i + 1, pair.Pair.String(), pair.Count})
+	}
+	t.Render()
+}
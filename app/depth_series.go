@@ -0,0 +1,20 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/ojarva/subsurface-statistics/subsurfacetypes"
+)
+
+// printDepthSeries writes the per-minute depth of each valid dive as CSV with columns dive, minute and depth.
+func printDepthSeries(dives []subsurfacetypes.Dive) {
+	fmt.Fprintln(output, "dive,minute,depth")
+	for i := range dives {
+		if dives[i].IsInvalid() {
+			continue
+		}
+		for _, point := range dives[i].DepthPerMinute() {
+			fmt.Fprintf(output, "%s,%d,%.1f\n", dives[i].Number, point.Minute, point.Depth)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/ojarva/subsurface-statistics/counter"
+	"github.com/ojarva/subsurface-statistics/subsurfacetypes"
+)
+
+// listedDive is a row of the dive list.
+type listedDive struct {
+	Number   int
+	Date     time.Time // Zero for dives without a date
+	Site     string
+	Duration time.Duration
+	MaxDepth float64
+	Rating   int
+}
+
+// diveListSorters are the -sort fields that apply to the dive list.
+var diveListSorters = map[string]func(d1, d2 *listedDive) bool{
+	"number":   func(d1, d2 *listedDive) bool { return d1.Number < d2.Number },
+	"date":     func(d1, d2 *listedDive) bool { return d1.Date.Before(d2.Date) },
+	"site":     func(d1, d2 *listedDive) bool { return d1.Site < d2.Site },
+	"duration": func(d1, d2 *listedDive) bool { return d1.Duration < d2.Duration },
+	"depth":    func(d1, d2 *listedDive) bool { return d1.MaxDepth < d2.MaxDepth },
+	"rating":   func(d1, d2 *listedDive) bool { return d1.Rating < d2.Rating },
+}
+
+// listDives returns a row for each valid dive, sorted by the comma-separated fields in sortBy. Prefix a
+// field with - for descending order. Dives without a date sort last by date in either order. An empty
+// sortBy keeps the divelog order; other fields than those in diveListSorters are an error.
+func listDives(dives []subsurfacetypes.Dive, diveSites *diveSiteMap, sortBy string) ([]listedDive, error) {
+	var rows []listedDive
+	for i := range dives {
+		if dives[i].IsInvalid() {
+			continue
+		}
+		row := listedDive{
+			Site:     diveSites.Location(&dives[i]),
+			Duration: dives[i].Duration(),
+			MaxDepth: dives[i].Depth().Max.Value,
+			Rating:   dives[i].RatingStars(),
+		}
+		row.Number, _ = dives[i].NumberInt()
+		if dives[i].HasDate() {
+			row.Date = dives[i].DateTime()
+		}
+		rows = append(rows, row)
+	}
+	var chain []func(d1, d2 *listedDive) bool
+	for _, key := range strings.Split(sortBy, ",") {
+		key = strings.TrimSpace(key)
+		if key == "" {
+			continue
+		}
+		field := strings.TrimPrefix(key, "-")
+		less, ok := diveListSorters[field]
+		if !ok {
+			return nil, fmt.Errorf("invalid sort field %q for -list: use number, date, site, duration, depth or rating", key)
+		}
+		if field == "date" {
+			chain = append(chain, func(d1, d2 *listedDive) bool { return !d1.Date.IsZero() && d2.Date.IsZero() })
+		}
+		if strings.HasPrefix(key, "-") {
+			ascending := less
+			less = func(d1, d2 *listedDive) bool { return ascending(d2, d1) }
+		}
+		chain = append(chain, less)
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		for _, less := range chain {
+			if less(&rows[i], &rows[j]) {
+				return true
+			}
+			if less(&rows[j], &rows[i]) {
+				return false
+			}
+		}
+		return false
+	})
+	return rows, nil
+}
+
+func printDiveList(rows []listedDive) {
+	t := table.NewWriter()
+	t.SetOutputMirror(output)
+	t.SetStyle(counter.TableStyle)
+	t.AppendHeader(table.Row{"Sukellus", "Päivämäärä", "Paikka", "Kesto", "Maksimisyvyys", "Arvosana"})
+	t.AppendSeparator()
+	for _, row := range rows {
+		date := ""
+		if !row.Date.IsZero() {
+			date = row.Date.Format("2006-01-02")
+		}
+		t.AppendRow([]interface{}{row.Number, date, row.Site, fmt.Sprintf("%.0f min", row.Duration.Minutes()), fmt.Sprintf("%.1f m", row.MaxDepth), fmt.Sprintf("%d/5", row.Rating)})
+	}
+	t.Render()
+}
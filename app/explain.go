@@ -0,0 +1,31 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/ojarva/subsurface-statistics/stattype"
+	"github.com/ojarva/subsurface-statistics/subsurfacetypes"
+)
+
+// explainDive describes which duration, depth and temperature slots the dive falls into.
+func explainDive(dive *subsurfacetypes.Dive) string {
+	duration := dive.Duration()
+	maxDepth := dive.Depth().Max.Value
+	meanDepth := dive.Depth().Mean.Value
+	temperature := dive.WaterTemperature().Value
+	return fmt.Sprintf("Dive %s: duration %v -> %s, max depth %.1f m -> %s, mean depth %.1f m -> %s, temperature %.1f C -> %s",
+		dive.Number,
+		duration, slotters[stattype.DiveLength].Slot(duration.Minutes()),
+		maxDepth, slotters[stattype.MaxDepth].Slot(maxDepth),
+		meanDepth, slotters[stattype.MeanDepth].Slot(meanDepth),
+		temperature, slotters[stattype.Temperature].Slot(temperature))
+}
+
+func printExplanations(dives []subsurfacetypes.Dive) {
+	for i := range dives {
+		if dives[i].IsInvalid() {
+			continue
+		}
+		fmt.Fprintln(output, explainDive(&dives[i]))
+	}
+}
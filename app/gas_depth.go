@@ -0,0 +1,66 @@
+package main
+
+import (
+	"sort"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/ojarva/subsurface-statistics/counter"
+	"github.com/ojarva/subsurface-statistics/subsurfacetypes"
+)
+
+// depthBandGas is the most common bottom gas in a max depth band.
+type depthBandGas struct {
+	Band     string
+	Gas      string
+	Count    int     // Dives in the band with the gas
+	Dives    int     // All dives in the band
+	minDepth float64 // Shallowest max depth in the band, used for ordering the bands
+}
+
+// gasPerDepthBand returns the most common bottom gas classification for each max depth band, from the
+// shallowest band to the deepest. Ties are broken by gas name.
+func gasPerDepthBand(dives []subsurfacetypes.Dive) []depthBandGas {
+	counts := make(map[string]map[string]int)
+	bands := make(map[string]*depthBandGas)
+	for i := range dives {
+		if dives[i].IsInvalid() {
+			continue
+		}
+		maxDepth := dives[i].Depth().Max.Value
+		band := subsurfacetypes.MaxDepthToSlot(maxDepth)
+		if _, ok := bands[band]; !ok {
+			bands[band] = &depthBandGas{Band: band, minDepth: maxDepth}
+			counts[band] = make(map[string]int)
+		}
+		if maxDepth < bands[band].minDepth {
+			bands[band].minDepth = maxDepth
+		}
+		bands[band].Dives++
+		counts[band][dives[i].BottomGas().Classification()]++
+	}
+	result := make([]depthBandGas, 0, len(bands))
+	for band, bandGas := range bands {
+		for gas, count := range counts[band] {
+			if count > bandGas.Count || count == bandGas.Count && gas < bandGas.Gas {
+				bandGas.Gas, bandGas.Count = gas, count
+			}
+		}
+		result = append(result, *bandGas)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].minDepth < result[j].minDepth
+	})
+	return result
+}
+
+func printGasPerDepthBand(bands []depthBandGas) {
+	t := table.NewWriter()
+	t.SetOutputMirror(output)
+	t.SetStyle(counter.TableStyle)
+	t.AppendHeader(table.Row{"Syvyys", "Yleisin kaasu", "Kertoja", "Sukelluksia"})
+	t.AppendSeparator()
+	for _, band := range bands {
+		t.AppendRow([]interface{}{band.Band, band.Gas, band.Count, band.Dives})
+	}
+	t.Render()
+}
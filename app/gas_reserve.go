@@ -0,0 +1,43 @@
+package main
+
+import (
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/ojarva/subsurface-statistics/counter"
+	"github.com/ojarva/subsurface-statistics/subsurfacetypes"
+)
+
+// lowRBTDive is a dive where remaining bottom time dropped below the threshold.
+type lowRBTDive struct {
+	Number string
+	Date   time.Time
+	MinRBT time.Duration
+}
+
+// lowRBTDives returns valid dives where the remaining bottom time went below threshold, indicating a tight gas situation.
+func lowRBTDives(dives []subsurfacetypes.Dive, threshold time.Duration) []lowRBTDive {
+	var result []lowRBTDive
+	for i := range dives {
+		if dives[i].IsInvalid() {
+			continue
+		}
+		minRBT, ok := dives[i].MinRBT()
+		if ok && minRBT < threshold {
+			result = append(result, lowRBTDive{dives[i].Number, dives[i].Date.Value, minRBT})
+		}
+	}
+	return result
+}
+
+func printLowRBTDives(dives []lowRBTDive) {
+	t := table.NewWriter()
+	t.SetOutputMirror(output)
+	t.SetStyle(counter.TableStyle)
+	t.AppendHeader(table.Row{"Sukellus", "Päivämäärä", "Pienin RBT"})
+	t.AppendSeparator()
+	for _, dive := range dives {
+		t.AppendRow([]interface{}{dive.Number, dive.Date.Format("2006-01-02"), dive.MinRBT.String()})
+	}
+	t.Render()
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/ojarva/subsurface-statistics/subsurfacetypes"
+)
+
+// goalWindow is the period used for calculating the current dive rate.
+const goalWindow = 365 * 24 * time.Hour
+
+var errNotEnoughRecentDives = errors.New("viimeisen 365 päivän aikana ei ole tarpeeksi sukelluksia tavoitteen arvioimiseen")
+
+// goalProjection tells when the goal is reached at the current dive rate.
+type goalProjection struct {
+	Goal        int
+	TotalDives  int
+	RecentDives int       // Dives in the last 365 days
+	Reached     bool      // The goal has already been reached
+	Date        time.Time // Projected date for reaching the goal
+}
+
+// projectGoal extrapolates the dive rate of the last 365 days to estimate when the total number of
+// valid dives reaches goal.
+func projectGoal(dives []subsurfacetypes.Dive, reference time.Time, goal int) (goalProjection, error) {
+	projection := goalProjection{Goal: goal}
+	windowStart := reference.Add(-goalWindow)
+	for i := range dives {
+		if dives[i].IsInvalid() {
+			continue
+		}
+		projection.TotalDives++
+		if dives[i].HasDate() && dives[i].DateTime().After(windowStart) && !dives[i].DateTime().After(reference) {
+			projection.RecentDives++
+		}
+	}
+	remaining := goal - projection.TotalDives
+	if remaining <= 0 {
+		projection.Reached = true
+		return projection, nil
+	}
+	if projection.RecentDives == 0 {
+		return projection, errNotEnoughRecentDives
+	}
+	divesPerDay := float64(projection.RecentDives) / goalWindow.Hours() * 24
+	days := int(math.Ceil(float64(remaining) / divesPerDay))
+	projection.Date = reference.AddDate(0, 0, days)
+	return projection, nil
+}
+
+func (gp goalProjection) String() string {
+	if gp.Reached {
+		return fmt.Sprintf("Tavoite %d sukellusta saavutettu (%d sukellusta)", gp.Goal, gp.TotalDives)
+	}
+	return fmt.Sprintf("Tavoite %d sukellusta saavutetaan arviolta %s (%d sukellusta, %d viimeisen vuoden aikana)",
+		gp.Goal, gp.Date.Format("2006-01-02"), gp.TotalDives, gp.RecentDives)
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/ojarva/subsurface-statistics/counter"
+	"github.com/ojarva/subsurface-statistics/stattype"
+	"github.com/ojarva/subsurface-statistics/subsurfacetypes"
+)
+
+// groupKeys returns a function that tells the group of a dive for -group-by: year, month or site. Dives
+// at sites excluded with -exclude-site have no group when grouping by site.
+func groupKeys(groupBy string, diveSites *diveSiteMap) (func(dive *subsurfacetypes.Dive) string, error) {
+	switch groupBy {
+	case "year":
+		return func(dive *subsurfacetypes.Dive) string {
+			return yearLabel(diveYear(dive))
+		}, nil
+	case "month":
+		return func(dive *subsurfacetypes.Dive) string {
+			if !dive.HasDate() {
+				return "unknown"
+			}
+			return dive.Date.Value.Format("2006-01")
+		}, nil
+	case "site":
+		return func(dive *subsurfacetypes.Dive) string {
+			if location, counted := diveSites.countedLocation(dive); counted {
+				return location
+			}
+			return ""
+		}, nil
+	default:
+		return nil, fmt.Errorf("invalid group %q: use year, month or site", groupBy)
+	}
+}
+
+// groupedStats computes the statistics separately for each group of dives. The result has, for each
+// statistic, the count of each entry in each group. Dives without a group are left out. Entries are
+// matched across groups by key, so that a buddy spelled differently in different years is a single row
+// named by the most common spelling.
+func groupedStats(dives []subsurfacetypes.Dive, diveSites *diveSiteMap, groupKey func(dive *subsurfacetypes.Dive) string) map[stattype.StatType]map[string]map[string]int {
+	groups := make(map[string]statsContainerMap)
+	all := make(statsContainerMap)
+	for i := range dives {
+		key := groupKey(&dives[i])
+		if key == "" {
+			continue
+		}
+		if groups[key] == nil {
+			groups[key] = make(statsContainerMap)
+		}
+		stats := groups[key]
+		processDive(&dives[i], &stats, diveSites)
+		processDive(&dives[i], &all, diveSites)
+	}
+	result := make(map[stattype.StatType]map[string]map[string]int)
+	for group, stats := range groups {
+		for statType, entries := range stats {
+			if result[statType] == nil {
+				result[statType] = make(map[string]map[string]int)
+			}
+			names := all[statType].Names()
+			for key, count := range entries.Counts() {
+				name := names[key]
+				if result[statType][name] == nil {
+					result[statType][name] = make(map[string]int)
+				}
+				result[statType][name][group] = count
+			}
+		}
+	}
+	return result
+}
+
+// printGroupedStats prints a pivot table for each statistic, with a row for each entry and a column for each group.
+func printGroupedStats(grouped map[stattype.StatType]map[string]map[string]int) {
+	for _, statType := range stattype.AllStatTypes() {
+		if counts, ok := grouped[statType]; ok {
+			printPivotTable(statType.String(), counts)
+		}
+	}
+}
+
+// printPivotTable prints a table with a row for each name and a column for each group. Rows are sorted
+// by total count, the largest first, and columns by group.
+func printPivotTable(nameHeader string, counts map[string]map[string]int) {
+	groupSet := map[string]bool{}
+	totals := map[string]int{}
+	names := make([]string, 0, len(counts))
+	for name, groups := range counts {
+		names = append(names, name)
+		for group, count := range groups {
+			groupSet[group] = true
+			totals[name] += count
+		}
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if totals[names[i]] != totals[names[j]] {
+			return totals[names[i]] > totals[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	groups := make([]string, 0, len(groupSet))
+	for group := range groupSet {
+		groups = append(groups, group)
+	}
+	sort.Strings(groups)
+	t := table.NewWriter()
+	t.SetOutputMirror(output)
+	t.SetStyle(counter.TableStyle)
+	header := table.Row{nameHeader}
+	for _, group := range groups {
+		header = append(header, group)
+	}
+	header = append(header, "Yhteensä")
+	t.AppendHeader(header)
+	t.AppendSeparator()
+	for _, name := range names {
+		row := table.Row{name}
+		for _, group := range groups {
+			row = append(row, counts[name][group])
+		}
+		row = append(row, totals[name])
+		t.AppendRow(row)
+	}
+	t.Render()
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"sort"
+	"strconv"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/ojarva/subsurface-statistics/counter"
+	"github.com/ojarva/subsurface-statistics/subsurfacetypes"
+)
+
+// unknownYear is used for dives without a date.
+const unknownYear = 0
+
+// diveYear returns the year of the dive, or unknownYear if the dive has no date.
+func diveYear(dive *subsurfacetypes.Dive) int {
+	if !dive.HasDate() {
+		return unknownYear
+	}
+	return dive.Date.Value.Year()
+}
+
+func yearLabel(year int) string {
+	if year == unknownYear {
+		return "unknown"
+	}
+	return strconv.Itoa(year)
+}
+
+// diveSiteYears counts valid dives per dive site and year, e.g. for rendering a heatmap.
+// Dives without a known site are counted under their trip location, or "unknown". Sites excluded with
+// -exclude-site are left out. Undated dives are counted under unknownYear.
+func diveSiteYears(dives []subsurfacetypes.Dive, diveSites *diveSiteMap) map[string]map[int]int {
+	result := make(map[string]map[int]int)
+	for i := range dives {
+		if dives[i].IsInvalid() {
+			continue
+		}
+		site, counted := diveSites.countedLocation(&dives[i])
+		if !counted {
+			continue
+		}
+		if result[site] == nil {
+			result[site] = make(map[int]int)
+		}
+		result[site][diveYear(&dives[i])]++
+	}
+	return result
+}
+
+// buddyYears counts valid dives per buddy and year, leaving out buddies excluded with -exclude-buddy.
+// Buddies are matched like in buddy statistics and shown with the same spelling. Undated dives are
+// counted under unknownYear.
+func buddyYears(dives []subsurfacetypes.Dive) map[string]map[int]int {
+	result := make(map[string]map[int]int)
+	spellings := buddySpellings(dives)
+	for i := range dives {
+		if dives[i].IsInvalid() {
+			continue
+		}
+		for _, buddy := range countedBuddies(&dives[i]) {
+			buddy = spellings[buddyKey(buddy)]
+			if result[buddy] == nil {
+				result[buddy] = make(map[int]int)
+			}
+			result[buddy][diveYear(&dives[i])]++
+		}
+	}
+	return result
+}
+
+// activeYears returns the number of distinct known years in years.
+func activeYears(years map[int]int) int {
+	active := 0
+	for year := range years {
+		if year != unknownYear {
+			active++
+		}
+	}
+	return active
+}
+
+// namesByName returns the names in counts in alphabetical order.
+func namesByName(counts map[string]map[int]int) []string {
+	names := make([]string, 0, len(counts))
+	for name := range counts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// namesByActiveYears returns the names in counts, the ones active in the most distinct years first.
+func namesByActiveYears(counts map[string]map[int]int) []string {
+	names := namesByName(counts)
+	sort.SliceStable(names, func(i, j int) bool {
+		return activeYears(counts[names[i]]) > activeYears(counts[names[j]])
+	})
+	return names
+}
+
+// printYearTable prints a table with a row for each of names, in order, and a column for each year.
+func printYearTable(nameHeader string, names []string, counts map[string]map[int]int) {
+	yearSet := map[int]bool{}
+	for _, years := range counts {
+		for year := range years {
+			yearSet[year] = true
+		}
+	}
+	years := make([]int, 0, len(yearSet))
+	for year := range yearSet {
+		years = append(years, year)
+	}
+	sort.Ints(years)
+	t := table.NewWriter()
+	t.SetOutputMirror(output)
+	t.SetStyle(counter.TableStyle)
+	header := table.Row{nameHeader}
+	for _, year := range years {
+		header = append(header, yearLabel(year))
+	}
+	t.AppendHeader(header)
+	t.AppendSeparator()
+	for _, name := range names {
+		row := table.Row{name}
+		for _, year := range years {
+			row = append(row, counts[name][year])
+		}
+		t.AppendRow(row)
+	}
+	t.Render()
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/ojarva/subsurface-statistics/subsurfacetypes"
+)
+
+var errNotEnoughDatedDives = errors.New("pisimmän tauon löytämiseen tarvitaan vähintään kaksi päivättyä sukellusta")
+
+// diveGap is the time between two consecutive dives.
+type diveGap struct {
+	From time.Time // Start of the dive before the break
+	To   time.Time // Start of the dive after the break
+}
+
+func (g diveGap) Duration() time.Duration {
+	return g.To.Sub(g.From)
+}
+
+func (g diveGap) String() string {
+	return fmt.Sprintf("Pisin tauko %.0f päivää (%s - %s)", g.Duration().Hours()/24, g.From.Format("2006-01-02"), g.To.Format("2006-01-02"))
+}
+
+// longestGap returns the longest time between the starts of two consecutive valid, dated dives.
+func longestGap(dives []subsurfacetypes.Dive) (diveGap, error) {
+	var starts []time.Time
+	for i := range dives {
+		if dives[i].IsInvalid() || !dives[i].HasDate() {
+			continue
+		}
+		starts = append(starts, dives[i].DateTime())
+	}
+	if len(starts) < 2 {
+		return diveGap{}, errNotEnoughDatedDives
+	}
+	sort.Slice(starts, func(i, j int) bool { return starts[i].Before(starts[j]) })
+	var longest diveGap
+	for i := 1; i < len(starts); i++ {
+		gap := diveGap{starts[i-1], starts[i]}
+		if gap.Duration() > longest.Duration() {
+			longest = gap
+		}
+	}
+	return longest, nil
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ojarva/subsurface-statistics/subsurfacetypes"
+)
+
+// decoNoFlyTime is the commonly recommended minimum surface interval before flying after a deco dive.
+const decoNoFlyTime = 24 * time.Hour
+
+// noFlyReport summarizes dives that call for a longer wait before flying.
+type noFlyReport struct {
+	DecoDives int
+	Last      time.Time     // End of the most recent dated deco dive; zero if there is none
+	SinceLast time.Duration // Time from surfacing from the most recent deco dive to the reference time
+}
+
+// decoDivesReport counts the valid dives that went into deco and finds the most recent one that ended before
+// reference. The no-fly time is counted from surfacing.
+func decoDivesReport(dives []subsurfacetypes.Dive, reference time.Time) noFlyReport {
+	var report noFlyReport
+	for i := range dives {
+		if dives[i].IsInvalid() || !dives[i].Deco().InDeco {
+			continue
+		}
+		report.DecoDives++
+		if !dives[i].HasDate() {
+			continue
+		}
+		end := dives[i].DateTime().Add(dives[i].Duration())
+		if !end.After(reference) && end.After(report.Last) {
+			report.Last = end
+		}
+	}
+	if !report.Last.IsZero() {
+		report.SinceLast = reference.Sub(report.Last)
+	}
+	return report
+}
+
+func (nr noFlyReport) String() string {
+	if nr.Last.IsZero() {
+		return fmt.Sprintf("Dekosukelluksia %d", nr.DecoDives)
+	}
+	s := fmt.Sprintf("Dekosukelluksia %d, viimeisin %s (%.0f päivää sitten)",
+		nr.DecoDives, nr.Last.Format("2006-01-02"), nr.SinceLast.Hours()/24)
+	if nr.SinceLast < decoNoFlyTime {
+		s += fmt.Sprintf(". Lentämistä suositellaan aikaisintaan %s", nr.Last.Add(decoNoFlyTime).Format("2006-01-02 15:04"))
+	}
+	return s
+}
@@ -1,67 +1,549 @@
 package main
 
 import (
-	"encoding/xml"
+	"context"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
+	"os/signal"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/jedib0t/go-pretty/v6/table"
 	"github.com/ojarva/subsurface-statistics/counter"
+	"github.com/ojarva/subsurface-statistics/stattype"
 
 	"github.com/ojarva/subsurface-statistics/subsurfacetypes"
 )
 
 const unknownDiveSite string = "unknown"
 
-var filenameFlag = flag.String("filename", "filename.ssrf", "Filename to be parsed")
-var sortByFlag = flag.String("sort", "count", "Field used for sorting")
+var filenameFlag = flag.String("filename", "filename.ssrf", "Filename to be parsed, or a directory of dive XML fragments")
+var sortByFlag = flag.String("sort", "count", "Comma-separated fields used for sorting: name, count, sinceFirst, sinceLast, month, stale, order or time. Prefix with - for descending order")
+var statsFlag = flag.String("stats", "", "Comma-separated list of statistics to compute, e.g. Buddies,DiveSite (empty for all)")
+var formatFlag = flag.String("format", "table", "Output format for statistics: table, jsonl or prometheus")
+var timeUnitFlag = flag.String("time-unit", "days", "Unit for time since first and last occurrence: days or hours")
+var outputFlag = flag.String("output", "", "File to write the statistics to (default stdout)")
+var quietFlag = flag.Bool("quiet", false, "Do not print warnings about values that could not be parsed")
+var yearToDateFlag = flag.Bool("year-to-date", false, "Show dives done this year so far compared to previous years")
+var compareYearsFlag = flag.Int("compare-years", 3, "Number of previous years to compare with -year-to-date")
+var validateFlag = flag.Bool("validate", false, "Check dives for data entry errors, such as swapped cylinder pressures")
+var timeseriesFlag = flag.Bool("timeseries", false, "Show the number of dives per week")
+var fromFlag = flag.String("from", "", "Only include dives on or after the given day (YYYY-MM-DD). Dives without a date are left out")
+var toFlag = flag.String("to", "", "Only include dives on or before the given day (YYYY-MM-DD). Dives without a date are left out")
+var asOfFlag = flag.String("as-of", "", "Compute statistics as they were at the end of the given day (YYYY-MM-DD)")
+var rbtThresholdFlag = flag.Duration("rbt-threshold", 0, "List dives where remaining bottom time dropped below this, e.g. 5m")
+var explainFlag = flag.Bool("explain", false, "Show which duration, depth and temperature slots each dive falls into")
+var totalDepthFlag = flag.Bool("total-depth", false, "Show the sum of max depths of all dives")
+var noDedupCylindersFlag = flag.Bool("no-dedup-cylinders", false, "Count every cylinder, even if a dive has several of the same size")
+var siteYearsFlag = flag.Bool("site-years", false, "Show the number of dives per dive site and year")
+var siteKeywordsFlag = flag.String("site-keywords", "wreck,cave,wall,reef", "Comma-separated keywords searched from dive site notes and descriptions")
+var stateFlag = flag.String("state", "", "File for saving statistics between runs; only dives not seen before are added. Runs must use the same counting flags, such as -stats and -units")
+var goalFlag = flag.Int("goal", 0, "Project when the total number of dives reaches this goal")
+var mergeSitesFlag = flag.Bool("merge-sites", false, "Merge dive sites with the same name, ignoring case and extra whitespace")
+var mergeSitesDistanceFlag = flag.Float64("merge-sites-distance", 0, "With -merge-sites, also merge dive sites closer than this many meters")
+var temperatureBandsFlag = flag.String("temperature-bands", "", "Comma-separated upper bounds of temperature bands in °C, e.g. -4,-2,0,5,10. Defaults to 0,5,10,15,20. With -units imperial, bounds are in °F and default to 40,50,60,70,80")
+var anonymizeFlag = flag.Bool("anonymize", false, "Replace buddy and divemaster names with pseudonyms such as \"Buddy #1\" in all output, including the file written with -export")
+var validateOnlyFlag = flag.Bool("validate-only", false, "Only parse and validate the divelog, print counts of parsed dives, sites and trips, and skip statistics")
+var durationBandsFlag = flag.String("duration-bands", "", "Comma-separated upper bounds of dive length bands in minutes, e.g. 2,5,10,30. Defaults to 10,20,...,90")
+var tripsFlag = flag.Bool("trips", false, "Print a summary of each trip")
+var exportFlag = flag.String("export", "", "Write the dives left after filtering, and the dive sites they refer to, to this file as Subsurface XML")
+var totalGasFlag = flag.Bool("total-gas", false, "Print the total amount of gas breathed, calculated from cylinder sizes and pressures")
+var noFlyFlag = flag.Bool("nofly", false, "Print the number of deco dives and the date of the most recent one")
+var distributionFlag = flag.Bool("distribution", false, "Show the smallest, largest and average value in each dive length, depth and temperature slot")
+var gasPerDepthFlag = flag.Bool("gas-per-depth", false, "Print the most common bottom gas for each max depth range")
+var summaryFlag = flag.Bool("summary", false, "Print only the number of entries and the total count of each statistic instead of the tables")
+var buddyYearsFlag = flag.Bool("buddy-years", false, "Show the number of dives per buddy and year, buddies active in the most years first")
+var unitsFlag = flag.String("units", "metric", "Units used in output: metric or imperial")
+var depthSeriesFlag = flag.Bool("depth-series", false, "Print the depth of each dive at every minute as CSV, for plotting")
+var colorFlag = flag.String("color", "auto", "Color tables: auto (only when writing to a terminal), always or never")
+var extraDataKeyFlag = flag.String("extradata-key", "", "Dive computer extra data key with a numeric value, e.g. \"Battery at end\", to bucket dives by")
+var extraDataStepFlag = flag.Float64("extradata-step", 1, "Width of the value ranges used with -extradata-key")
+var longestGapFlag = flag.Bool("longest-gap", false, "Print the longest break between two dives")
+var groupByFlag = flag.String("group-by", "", "Break the statistics down by year, month or site instead of printing the usual tables")
+var bestMixFlag = flag.Bool("best-mix", false, "List dives where a richer nitrox mix could have been used at 1.4 bar ppO2")
+var rollingFlag = flag.Bool("rolling", false, "Show how the number of dives in the trailing 12 months has changed, month by month")
+var slotterConfigFlag = flag.String("slotter-config", "", "JSON file with custom slots for statistics with numeric slots, overriding -duration-bands and -temperature-bands. Lengths are in minutes and temperatures in °C")
+var computerFlag = flag.String("computer", "", "Only include dives from dive computers whose model contains this text, ignoring case, e.g. perdix")
+var listFlag = flag.Bool("list", false, "List the dives one per row instead of printing statistics. -sort accepts number, date, site, duration, depth and rating; without -sort dives are listed in divelog order")
+var buddyPairsFlag = flag.Int("buddy-pairs", 0, "Number of most common buddy pairs to show (0 to disable)")
 
-type statsContainerMap map[statType]counter.LastCounterStats
+type statsContainerMap map[stattype.StatType]counter.LastCounterStats
 
-func (scm *statsContainerMap) Add(statType statType, name string, timeSince *time.Duration) {
+// output is where reports are written to.
+var output io.Writer = os.Stdout
+
+// closeOutput closes the -output file, if one is open.
+var closeOutput = func() {}
+
+// exit closes the -output file and exits with code. os.Exit skips deferred calls, so the output file
+// would not be closed otherwise.
+func exit(code int) {
+	closeOutput()
+	os.Exit(code)
+}
+
+// referenceTime is the time recency is calculated against. It is changed with -as-of.
+var referenceTime = wallClock(time.Now())
+
+// wallClock returns the local wall clock time of t in UTC. Dive times are local times parsed as UTC, so
+// they can only be compared with times in the same form.
+func wallClock(t time.Time) time.Time {
+	t = t.Local()
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+}
+
+// siteKeywords are the keywords set with -site-keywords.
+var siteKeywords []string
+
+// slotters bucket the values of the statistics with numeric slots. Dive lengths are in minutes and
+// temperatures in °C. -duration-bands, -temperature-bands, -units and -slotter-config replace the defaults.
+var slotters = map[stattype.StatType]subsurfacetypes.Slotter{
+	stattype.DiveLength:     subsurfacetypes.NewDurationSlotter().Minutes(),
+	stattype.MeanDepth:      subsurfacetypes.MeanDepthSlotter,
+	stattype.MaxDepth:       subsurfacetypes.MaxDepthSlotter,
+	stattype.DepthProfile:   subsurfacetypes.DepthRatioSlotter,
+	stattype.Temperature:    subsurfacetypes.NewTemperatureSlotter(),
+	stattype.MinTemperature: subsurfacetypes.NewTemperatureSlotter(),
+	stattype.AirTemperature: subsurfacetypes.NewTemperatureSlotter(),
+	stattype.SAC:            subsurfacetypes.SacSlotter,
+	stattype.EAD:            subsurfacetypes.EADSlotter,
+	stattype.AscentRate:     subsurfacetypes.AscentRateSlotter,
+}
+
+// setTemperatureSlotter uses slotter for all temperature statistics.
+func setTemperatureSlotter(slotter subsurfacetypes.Slotter) {
+	slotters[stattype.Temperature] = slotter
+	slotters[stattype.MinTemperature] = slotter
+	slotters[stattype.AirTemperature] = slotter
+}
+
+// imperialUnits is set with -units imperial. Values are always computed in metric units and only
+// converted for output.
+var imperialUnits bool
+
+// surfaceIntervals holds the surface interval before each dive, keyed by dive start time.
+var surfaceIntervals map[time.Time]time.Duration
+
+// stringListFlag is a flag that can be given several times. Values are compared case-insensitively.
+type stringListFlag []string
+
+func (s *stringListFlag) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *stringListFlag) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
+
+// Contains returns true if value is one of the given values, ignoring case and extra whitespace.
+func (s *stringListFlag) Contains(value string) bool {
+	for _, v := range *s {
+		if strings.EqualFold(collapseWhitespace(v), collapseWhitespace(value)) {
+			return true
+		}
+	}
+	return false
+}
+
+// excludedBuddies and excludedSites are left out of buddy and dive site statistics. They are set with
+// -exclude-buddy and -exclude-site.
+var excludedBuddies, excludedSites stringListFlag
+
+// buddyKey returns the key buddies are matched by, so that names differing only by case and whitespace
+// are the same buddy.
+func buddyKey(buddy string) string {
+	return strings.ToLower(collapseWhitespace(buddy))
+}
+
+// countedBuddies returns the distinct buddies of the dive with extra whitespace removed, leaving out
+// empty names and the buddies excluded with -exclude-buddy. A buddy listed twice is returned once,
+// with the spelling listed first.
+func countedBuddies(dive *subsurfacetypes.Dive) []string {
+	var buddies []string
+	seen := map[string]bool{}
+	for _, buddy := range dive.BuddyList() {
+		buddy = collapseWhitespace(buddy)
+		key := buddyKey(buddy)
+		if buddy == "" || seen[key] || excludedBuddies.Contains(buddy) {
+			continue
+		}
+		seen[key] = true
+		buddies = append(buddies, buddy)
+	}
+	return buddies
+}
+
+// buddySpellings returns the most common spelling of each buddy in the valid dives by buddyKey, the
+// same spelling that buddy statistics show. Ties go to the spelling seen first.
+func buddySpellings(dives []subsurfacetypes.Dive) map[string]string {
+	counts := map[string]int{}
+	spellings := map[string]string{}
+	for i := range dives {
+		if dives[i].IsInvalid() {
+			continue
+		}
+		for _, buddy := range countedBuddies(&dives[i]) {
+			counts[buddy]++
+			key := buddyKey(buddy)
+			if spelling, ok := spellings[key]; !ok || counts[buddy] > counts[spelling] {
+				spellings[key] = buddy
+			}
+		}
+	}
+	return spellings
+}
+
+// countedLocation returns the location of the dive like Location, and false if the location is
+// excluded with -exclude-site.
+func (dsm diveSiteMap) countedLocation(dive *subsurfacetypes.Dive) (string, bool) {
+	location := dsm.Location(dive)
+	return location, !excludedSites.Contains(location)
+}
+
+func init() {
+	flag.Var(&excludedBuddies, "exclude-buddy", "Leave the buddy out of buddy statistics, including group sizes; can be given several times")
+	flag.Var(&excludedSites, "exclude-site", "Leave the dive site out of dive site statistics; can be given several times")
+}
+
+// enabledStats holds the statistics selected with -stats. nil enables everything.
+var enabledStats map[stattype.StatType]bool
+
+// statEnabled returns true if statType is selected with -stats. processDive checks it before computing
+// values that need the dive profile.
+func statEnabled(statType stattype.StatType) bool {
+	return enabledStats == nil || enabledStats[statType]
+}
+
+func (scm *statsContainerMap) Add(statType stattype.StatType, name string, occurrence counter.Occurrence) {
+	scm.AddVariant(statType, name, name, occurrence)
+}
+
+// AddVariant adds an entry under key; name is the spelling shown if it is the most common one.
+func (scm *statsContainerMap) AddVariant(statType stattype.StatType, key, name string, occurrence counter.Occurrence) {
+	if !statEnabled(statType) {
+		return
+	}
+	_, exists := (*scm)[statType]
+	if !exists {
+		(*scm)[statType] = make(counter.LastCounterStats)
+	}
+	(*scm)[statType].AddVariant(key, name, occurrence)
+}
+
+// AddValue adds an entry and, with -distribution, records value for the min, max and average columns.
+// value is in metric units and converted with displayValue. Unknown values are not recorded.
+func (scm *statsContainerMap) AddValue(statType stattype.StatType, name string, value float64, known bool, occurrence counter.Occurrence) {
+	if !*distributionFlag || !known {
+		scm.Add(statType, name, occurrence)
+		return
+	}
+	if !statEnabled(statType) {
+		return
+	}
 	_, exists := (*scm)[statType]
 	if !exists {
 		(*scm)[statType] = make(counter.LastCounterStats)
 	}
-	(*scm)[statType].Add(name, timeSince)
+	value, unit := displayValue(statType, value)
+	(*scm)[statType].AddValue(name, value, unit, occurrence)
 }
 
-type statType int
+// AddOrdered adds an entry with a natural order used by the "order" sort key.
+func (scm *statsContainerMap) AddOrdered(statType stattype.StatType, name string, order int, occurrence counter.Occurrence) {
+	if !statEnabled(statType) {
+		return
+	}
+	_, exists := (*scm)[statType]
+	if !exists {
+		(*scm)[statType] = make(counter.LastCounterStats)
+	}
+	(*scm)[statType].AddOrdered(name, order, occurrence)
+}
 
-//go:generate stringer -type=statType
-const (
-	DiveLength statType = iota
-	Buddies
-	Cylinders
-	MeanDepth
-	MaxDepth
-	Temperature
-	DiveSite
-	TagStat
-)
+// displayValue converts a value recorded with -distribution from metric units to the units set with
+// -units, and returns it with its unit.
+func displayValue(statType stattype.StatType, value float64) (float64, string) {
+	switch statType {
+	case stattype.DiveLength:
+		return value, "min"
+	case stattype.MeanDepth, stattype.MaxDepth:
+		if imperialUnits {
+			return subsurfacetypes.MetersToFeet(value), "ft"
+		}
+		return value, "m"
+	case stattype.Temperature, stattype.MinTemperature, stattype.AirTemperature:
+		if imperialUnits {
+			return subsurfacetypes.CelsiusToFahrenheit(value), "F"
+		}
+		return value, "C"
+	case stattype.AscentRate:
+		if imperialUnits {
+			return subsurfacetypes.MetersToFeet(value), "ft/min"
+		}
+		return value, "m/min"
+	default:
+		return value, ""
+	}
+}
 
-type diveSiteMap map[string]string
+// collapseWhitespace trims name and replaces internal runs of whitespace with a single space.
+func collapseWhitespace(name string) string {
+	return strings.Join(strings.Fields(name), " ")
+}
+
+// parseStatTypes parses a comma-separated list of statistic names. Names are case-insensitive.
+// An empty list returns nil, which enables all statistics.
+func parseStatTypes(value string) (map[stattype.StatType]bool, error) {
+	if strings.TrimSpace(value) == "" {
+		return nil, nil
+	}
+	selected := make(map[stattype.StatType]bool)
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		found := false
+		for _, statType := range stattype.AllStatTypes() {
+			if strings.EqualFold(statType.String(), name) {
+				selected[statType] = true
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown statistic %q", name)
+		}
+	}
+	return selected, nil
+}
+
+// perDiveStats lists statistics where each dive is counted exactly once, so total dive time is meaningful.
+var perDiveStats = map[stattype.StatType]bool{
+	stattype.DiveLength:     true,
+	stattype.MeanDepth:      true,
+	stattype.MaxDepth:       true,
+	stattype.Temperature:    true,
+	stattype.DiveSite:       true,
+	stattype.GasSwitches:    true,
+	stattype.Deco:           true,
+	stattype.DiveComputer:   true,
+	stattype.Rating:         true,
+	stattype.DepthProfile:   true,
+	stattype.DecoModel:      true,
+	stattype.ReverseProfile: true,
+	stattype.SAC:            true,
+	stattype.BottomGas:      true,
+	stattype.GearSet:        true,
+	stattype.ExtraData:      true,
+	stattype.GroupSize:      true,
+	stattype.AscentRate:     true,
+	stattype.GFHigh:         true,
+}
+
+type diveSiteMap map[string]subsurfacetypes.Divesite
+
+// Exists returns false if id refers to a dive site missing from the divelog, e.g. a deleted site. Dives
+// without a dive site are fine.
+func (dsm diveSiteMap) Exists(id string) bool {
+	if subsurfacetypes.NormalizeDiveSiteID(id) == "" {
+		return true
+	}
+	_, found := dsm[subsurfacetypes.NormalizeDiveSiteID(id)]
+	return found
+}
 
 func (dsm diveSiteMap) FetchByID(id string) string {
-	diveSiteName, found := dsm[id]
+	diveSite, found := dsm[subsurfacetypes.NormalizeDiveSiteID(id)]
 	if found {
-		return diveSiteName
+		return diveSite.Name
 	}
 	return unknownDiveSite
 }
 
-func diveReceiver(c chan subsurfacetypes.Dive, wg *sync.WaitGroup, diveSites *diveSiteMap) {
+// Location returns the effective location of the dive: the name of its dive site, or the location of
+// its trip if the dive site is not known.
+func (dsm diveSiteMap) Location(dive *subsurfacetypes.Dive) string {
+	location := dsm.FetchByID(dive.DiveSiteID)
+	if tripLocation := collapseWhitespace(dive.TripLocation); location == unknownDiveSite && tripLocation != "" {
+		return tripLocation
+	}
+	return location
+}
+
+// KeywordsByID returns the keywords found in the notes or description of the dive site.
+func (dsm diveSiteMap) KeywordsByID(id string, keywords []string) []string {
+	diveSite, found := dsm[subsurfacetypes.NormalizeDiveSiteID(id)]
+	if !found {
+		return nil
+	}
+	return diveSite.MatchKeywords(keywords)
+}
+
+// mergeDiveSites merges sites with the same name after normalization, or sites closer than maxDistance meters
+// to each other, by giving them the name of the first such site. Distance is not used if maxDistance is zero.
+func mergeDiveSites(diveSites diveSiteMap, maxDistance float64) {
+	ids := make([]string, 0, len(diveSites))
+	for id := range diveSites {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	var merged []subsurfacetypes.Divesite
+	for _, id := range ids {
+		diveSite := diveSites[id]
+		for _, other := range merged {
+			sameName := diveSite.NormalizedName() == other.NormalizedName()
+			distance, err := diveSite.DistanceMeters(other)
+			if sameName || maxDistance > 0 && err == nil && distance <= maxDistance {
+				diveSite.Name = other.Name
+				diveSites[id] = diveSite
+				break
+			}
+		}
+		merged = append(merged, diveSite)
+	}
+}
+
+// feedDives sends dives to c until all of them are sent or ctx is cancelled, and closes c.
+func feedDives(ctx context.Context, c chan<- subsurfacetypes.Dive, dives []subsurfacetypes.Dive) error {
+	defer close(c)
+	for _, dive := range dives {
+		select {
+		case c <- dive:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// diveReceiver counts the dives received from c and prints the statistics once c is closed. If ctx is
+// cancelled, it returns without printing anything.
+func diveReceiver(ctx context.Context, c <-chan subsurfacetypes.Dive, wg *sync.WaitGroup, diveSites *diveSiteMap, state *diveStats) {
 	defer wg.Done()
-	statsContainer := make(statsContainerMap)
-	for dive := range c {
-		processDive(&dive, &statsContainer, diveSites)
+	for received := true; received; {
+		var dive subsurfacetypes.Dive
+		select {
+		case <-ctx.Done():
+			return
+		case dive, received = <-c:
+		}
+		if !received || !state.markSeen(&dive) {
+			continue
+		}
+		processDive(&dive, &state.Stats, diveSites)
+	}
+	if ctx.Err() != nil {
+		return
+	}
+	if *summaryFlag {
+		printStatsSummary(state.Stats)
+		return
+	}
+	if *formatFlag == "prometheus" {
+		if err := counter.WritePrometheusHeader(output); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		}
+	}
+	for statType, stats := range state.Stats {
+		// The sums are the number and the dive time of the counted dives only when each dive is counted
+		// once. Dives at sites excluded with -exclude-site are left out of the dive site totals.
+		var summary *counter.Summary
+		if perDiveStats[statType] {
+			summary = &counter.Summary{DiveTime: stats.TotalDuration()}
+		}
+		switch *formatFlag {
+		case "jsonl":
+			if err := stats.WriteJSONLines(output, statType.String(), *sortByFlag); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+			}
+		case "prometheus":
+			if err := stats.WritePrometheus(output, statType.String(), *sortByFlag); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+			}
+		default:
+			stats.PrintStats(output, *sortByFlag, summary)
+		}
+	}
+}
+
+// countDives counts the dives, prints the statistics and updates the -state file. It returns an error
+// if it was interrupted, in which case nothing is printed or saved.
+func countDives(dives []subsurfacetypes.Dive, diveSites *diveSiteMap) error {
+	settings, err := stateSettings()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		exit(2)
+	}
+	state := newDiveStats(referenceTime, settings)
+	if *stateFlag != "" {
+		if err := loadStateFile(*stateFlag, state); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			exit(2)
+		}
+	}
+	// Interrupting stops the processing without printing partial statistics or saving the state.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt)
+	defer signal.Stop(interrupts)
+	go func() {
+		select {
+		case <-interrupts:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	c := make(chan subsurfacetypes.Dive, 100)
+	var wg sync.WaitGroup
+
+	wg.Add(1)
+	go diveReceiver(ctx, c, &wg, diveSites, state)
+
+	err = feedDives(ctx, c, dives)
+	wg.Wait()
+	if err == nil {
+		err = ctx.Err()
+	}
+	if err != nil {
+		return err
 	}
-	for _, stats := range statsContainer {
-		stats.PrintStats(*sortByFlag)
+	if *stateFlag != "" {
+		if err := saveStateFile(*stateFlag, state); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		}
+	}
+	return nil
+}
+
+// printStatsSummary prints the number of entries and the total count of each statistic, one line per
+// statistic in declaration order. The total is the number of occurrences, which is the number of dives
+// only for statistics that count each dive once.
+func printStatsSummary(stats statsContainerMap) {
+	for _, statType := range stattype.AllStatTypes() {
+		if entries, ok := stats[statType]; ok {
+			fmt.Fprintf(output, "%s: %d nimeä, %d esiintymää\n", statType, len(entries), entries.TotalCount())
+		}
+	}
+}
+
+// groupSizeLabel buckets the number of distinct buddies on a dive. Buddies excluded with -exclude-buddy
+// are not counted, so leaving out e.g. an instructor makes the group smaller.
+func groupSizeLabel(buddies int) string {
+	switch {
+	case buddies == 0:
+		return "yksin"
+	case buddies == 1:
+		return "1 pari"
+	case buddies == 2:
+		return "2 paria"
+	default:
+		return "3+ paria"
 	}
 }
 
@@ -69,56 +551,183 @@ func processDive(dive *subsurfacetypes.Dive, statsContainer *statsContainerMap,
 	if dive.IsInvalid() {
 		return
 	}
-	timeSinceDive := dive.TimeSince()
-	buddies := dive.BuddyList()
+	// Dives without a date are counted, but they do not affect recency.
+	occurrence := counter.Occurrence{Duration: dive.Duration()}
+	if dive.HasDate() {
+		timeSince := dive.TimeSinceAt(referenceTime)
+		occurrence.TimeSince = &timeSince
+		occurrence.Date = dive.DateTime()
+	}
+	buddies := countedBuddies(dive)
 	for _, buddy := range buddies {
-		(*statsContainer).Add(Buddies, buddy, &timeSinceDive)
+		// Buddies are matched by buddyKey; the most common spelling is shown.
+		(*statsContainer).AddVariant(stattype.Buddies, buddyKey(buddy), buddy, occurrence)
 	}
+	if strings.TrimSpace(dive.Buddy) == "" {
+		(*statsContainer).Add(stattype.Buddies, "", occurrence)
+	}
+	(*statsContainer).AddOrdered(stattype.GroupSize, groupSizeLabel(len(buddies)), len(buddies), occurrence)
 	usedCylinders := map[string]bool{}
 	for _, cylinder := range dive.Cylinders {
 		// Deduplicate cylinders used in a single dive; subsurface occasionally creates duplicate cylinders.
-		// This won't work well for multiple stages with the same size but it's good enough for most cases.
-		_, ok := usedCylinders[cylinder.Size]
-		if ok {
+		// Cylinders are counted by size and gas, so cylinders of the same size with different gases are
+		// separate entries. This won't work well for multiple stages with the same size and gas but it's
+		// good enough for most cases. -no-dedup-cylinders counts every cylinder for such configurations.
+		label := cylinder.Size + " " + cylinder.GasMix().String()
+		if usedCylinders[label] && !*noDedupCylindersFlag {
+			continue
+		}
+		usedCylinders[label] = true
+		(*statsContainer).Add(stattype.Cylinders, label, occurrence)
+	}
+	(*statsContainer).Add(stattype.GearSet, dive.GearSet(), occurrence)
+	usedMaterials := map[string]bool{}
+	for _, cylinder := range dive.Cylinders {
+		material := cylinder.Material()
+		if usedMaterials[material] {
 			continue
 		}
-		usedCylinders[cylinder.Size] = true
-		(*statsContainer).Add(Cylinders, cylinder.Size, &timeSinceDive)
+		usedMaterials[material] = true
+		(*statsContainer).Add(stattype.CylinderMaterial, material, occurrence)
+	}
+	duration := dive.Duration()
+	(*statsContainer).AddValue(stattype.DiveLength, slotters[stattype.DiveLength].Slot(duration.Minutes()), duration.Minutes(), duration > 0, occurrence)
+	depth := dive.Depth()
+	(*statsContainer).AddValue(stattype.MeanDepth, slotters[stattype.MeanDepth].Slot(depth.Mean.Value), depth.Mean.Value, depth.Mean.Value > 0, occurrence)
+	(*statsContainer).AddValue(stattype.MaxDepth, slotters[stattype.MaxDepth].Slot(depth.Max.Value), depth.Max.Value, depth.Max.Value > 0, occurrence)
+	if statEnabled(stattype.DepthProfile) {
+		depthRatio, _ := dive.DepthRatio()
+		(*statsContainer).Add(stattype.DepthProfile, slotters[stattype.DepthProfile].Slot(depthRatio), occurrence)
+	}
+	water := dive.WaterTemperature()
+	(*statsContainer).AddValue(stattype.Temperature, slotters[stattype.Temperature].Slot(water.Value), water.Value, water.Valid, occurrence)
+	if diveSite, counted := diveSites.countedLocation(dive); counted {
+		(*statsContainer).Add(stattype.DiveSite, diveSite, occurrence)
+	}
+	if interval, ok := surfaceIntervals[dive.DateTime()]; ok && dive.HasDate() {
+		(*statsContainer).Add(stattype.SurfaceInterval, subsurfacetypes.SurfaceIntervalToSlot(interval), occurrence)
+	}
+	for _, keyword := range diveSites.KeywordsByID(dive.DiveSiteID, siteKeywords) {
+		(*statsContainer).Add(stattype.SiteKeyword, keyword, occurrence)
 	}
-	(*statsContainer).Add(DiveLength, subsurfacetypes.DurationToSlot(dive.Duration()), &timeSinceDive)
-	(*statsContainer).Add(MeanDepth, subsurfacetypes.MeanDepthToSlot(dive.DiveComputer.Depth.Mean.Value), &timeSinceDive)
-	(*statsContainer).Add(MaxDepth, subsurfacetypes.MaxDepthToSlot(dive.DiveComputer.Depth.Max.Value), &timeSinceDive)
-	(*statsContainer).Add(Temperature, subsurfacetypes.TemperatureToSlot(dive.DiveComputer.Temperature.Water.Value), &timeSinceDive)
-	diveSiteID := strings.TrimSpace(dive.DiveSiteID)
-	(*statsContainer).Add(DiveSite, diveSites.FetchByID(diveSiteID), &timeSinceDive)
 	for _, tag := range dive.Tags.Value {
-		(*statsContainer).Add(TagStat, tag, &timeSinceDive)
+		(*statsContainer).Add(stattype.TagStat, tag, occurrence)
+	}
+	if dive.HasDate() {
+		(*statsContainer).Add(stattype.Month, dive.Date.Value.Month().String(), occurrence)
+		// Weeks start on Monday, so that the "order" sort key lists weekdays from Monday to Sunday.
+		weekday := dive.Date.Value.Weekday()
+		(*statsContainer).AddOrdered(stattype.Weekday, weekday.String(), (int(weekday)+6)%7, occurrence)
+	}
+	if minTemperature, _ := dive.WaterTemperatureRange(); minTemperature.Valid {
+		(*statsContainer).AddValue(stattype.MinTemperature, slotters[stattype.MinTemperature].Slot(minTemperature.Value), minTemperature.Value, true, occurrence)
+	}
+	if airTemperature := dive.AirTemperature(); airTemperature.Valid {
+		(*statsContainer).AddValue(stattype.AirTemperature, slotters[stattype.AirTemperature].Slot(airTemperature.Value), airTemperature.Value, true, occurrence)
+	}
+	if statEnabled(stattype.SAC) {
+		sac, _ := dive.SACLitersPerMinute()
+		(*statsContainer).Add(stattype.SAC, slotters[stattype.SAC].Slot(sac), occurrence)
+	}
+	switch {
+	case !statEnabled(stattype.ReverseProfile):
+	case !dive.HasProfile():
+		(*statsContainer).Add(stattype.ReverseProfile, "unknown", occurrence)
+	case dive.ReverseProfile():
+		(*statsContainer).Add(stattype.ReverseProfile, "reverse profile", occurrence)
+	default:
+		(*statsContainer).Add(stattype.ReverseProfile, "normal profile", occurrence)
+	}
+	if statEnabled(stattype.AscentRate) {
+		if rate, ok := dive.FinalAscentRate(); ok {
+			(*statsContainer).AddValue(stattype.AscentRate, slotters[stattype.AscentRate].Slot(rate), rate, true, occurrence)
+		} else {
+			(*statsContainer).Add(stattype.AscentRate, "unknown", occurrence)
+		}
+	}
+	if statEnabled(stattype.SafetyStop) && dive.SafetyStopRequired(subsurfacetypes.DefaultSafetyStop) {
+		if dive.HasSafetyStop(subsurfacetypes.DefaultSafetyStop) {
+			(*statsContainer).Add(stattype.SafetyStop, "safety stop", occurrence)
+		} else {
+			(*statsContainer).Add(stattype.SafetyStop, "no safety stop", occurrence)
+		}
+	}
+	if *extraDataKeyFlag != "" && statEnabled(stattype.ExtraData) {
+		if value, ok := dive.PrimaryComputer().ExtraNumber(*extraDataKeyFlag); ok {
+			(*statsContainer).Add(stattype.ExtraData, subsurfacetypes.NumberToSlot(value, *extraDataStepFlag), occurrence)
+		} else {
+			(*statsContainer).Add(stattype.ExtraData, "unknown", occurrence)
+		}
+	}
+	decoModel := dive.DecoModel()
+	if decoModel == "" {
+		decoModel = "unknown"
+	}
+	(*statsContainer).Add(stattype.DecoModel, decoModel, occurrence)
+	if _, gfHigh, ok := dive.GradientFactors(); ok {
+		(*statsContainer).AddOrdered(stattype.GFHigh, fmt.Sprintf("GF high %d", gfHigh), gfHigh, occurrence)
+	} else {
+		(*statsContainer).Add(stattype.GFHigh, "unknown", occurrence)
+	}
+	stars := dive.RatingStars()
+	(*statsContainer).AddOrdered(stattype.Rating, fmt.Sprintf("%d/5", stars), stars, occurrence)
+	diveComputerModel := strings.TrimSpace(dive.PrimaryComputer().Model)
+	if diveComputerModel == "" {
+		diveComputerModel = "unknown"
+	}
+	(*statsContainer).Add(stattype.DiveComputer, diveComputerModel, occurrence)
+	if statEnabled(stattype.Deco) {
+		if dive.Deco().InDeco {
+			(*statsContainer).Add(stattype.Deco, "deco", occurrence)
+		} else {
+			(*statsContainer).Add(stattype.Deco, "no deco", occurrence)
+		}
+	}
+	if statEnabled(stattype.GasSwitches) {
+		if len(dive.GasChanges()) > 0 {
+			(*statsContainer).Add(stattype.GasSwitches, "multiple gases", occurrence)
+		} else {
+			(*statsContainer).Add(stattype.GasSwitches, "single gas", occurrence)
+		}
+	}
+	if statEnabled(stattype.BottomGas) {
+		(*statsContainer).Add(stattype.BottomGas, dive.BottomGas().String(), occurrence)
+	}
+	if statEnabled(stattype.EAD) {
+		if ead, ok := dive.EAD(); ok {
+			(*statsContainer).Add(stattype.EAD, slotters[stattype.EAD].Slot(ead), occurrence)
+		}
 	}
 }
 
 func diveSiteReceiver(c chan subsurfacetypes.Divesite, wg *sync.WaitGroup, diveSites *diveSiteMap) {
 	for diveSite := range c {
-		u := strings.TrimSpace(diveSite.UUID)
-		(*diveSites)[u] = diveSite.Name
+		(*diveSites)[subsurfacetypes.NormalizeDiveSiteID(diveSite.UUID)] = diveSite
 	}
 	wg.Done()
 }
 
 func readAndUnmarshal(filename string) subsurfacetypes.Divelog {
+	if info, err := os.Stat(filename); err == nil && info.IsDir() {
+		divelog, err := subsurfacetypes.ParseDiveFragments(filename)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			exit(3)
+		}
+		return *divelog
+	}
 	xmlFile, err := os.Open(filename)
 	if err != nil {
-		fmt.Println(err)
-		os.Exit(2)
+		fmt.Fprintln(os.Stderr, err)
+		exit(2)
 	}
 	defer xmlFile.Close()
-	rawXMLValue, _ := ioutil.ReadAll(xmlFile)
-	var divelog subsurfacetypes.Divelog
-	err = xml.Unmarshal(rawXMLValue, &divelog)
+	divelog, err := subsurfacetypes.ParseDivelog(xmlFile)
 	if err != nil {
-		fmt.Println(err)
-		os.Exit(3)
+		fmt.Fprintln(os.Stderr, err)
+		exit(3)
 	}
-	return divelog
+	return *divelog
 }
 
 func processDiveSites(divelog *subsurfacetypes.Divelog) diveSiteMap {
@@ -135,24 +744,412 @@ func processDiveSites(divelog *subsurfacetypes.Divelog) diveSiteMap {
 	return diveSites
 }
 
-func main() {
-	flag.Parse()
-	var wg sync.WaitGroup
-	divelog := readAndUnmarshal(*filenameFlag)
-	diveSites := processDiveSites(&divelog)
-	c := make(chan subsurfacetypes.Dive, 100)
+// isTerminal returns true if f is a terminal rather than a file or a pipe.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
 
-	wg.Add(1)
-	go diveReceiver(c, &wg, &diveSites)
+// warnAboutDives logs values that are skipped in the statistics, once per dive. processDive does not
+// warn, because -group-by processes the dives again.
+func warnAboutDives(dives []subsurfacetypes.Dive) {
+	for i := range dives {
+		if dives[i].IsInvalid() {
+			continue
+		}
+		if _, err := dives[i].ParseDuration(); err != nil && err != subsurfacetypes.ErrMissingValue {
+			subsurfacetypes.Warnings.Add(subsurfacetypes.DurationWarning, "Invalid duration:", dives[i].RawDuration)
+		}
+	}
+}
 
-	for _, trip := range divelog.Dives.Trips {
+// collectDives returns all dives in the divelog, including the ones inside trips. A dive that appears
+// both inside a trip and in the top level list is only returned once. Dives are matched by Identity:
+// the dive number, or the start time or dive computer ids for dives without a number. The copy inside
+// the trip wins, unless the top level copy has tripflag NOTRIP, which means the dive was removed from
+// the trip. Dives that cannot be identified are always included.
+func collectDives(divelog *subsurfacetypes.Divelog) []subsurfacetypes.Dive {
+	notInTrip := map[string]bool{}
+	for _, dive := range divelog.Dives.Dives {
+		if identity := dive.Identity(); identity != "" && dive.NotInTrip() {
+			notInTrip[identity] = true
+		}
+	}
+	var dives []subsurfacetypes.Dive
+	seen := map[string]bool{}
+	add := func(dive subsurfacetypes.Dive) {
+		identity := dive.Identity()
+		if identity != "" {
+			if seen[identity] {
+				return
+			}
+			seen[identity] = true
+		}
+		dives = append(dives, dive)
+	}
+	for tripIndex, trip := range divelog.Dives.Trips {
 		for _, dive := range trip.Dives {
-			c <- dive
+			if notInTrip[dive.Identity()] {
+				continue
+			}
+			dive.TripLocation = trip.Location
+			dive.TripNumber = tripIndex + 1
+			add(dive)
 		}
 	}
 	for _, dive := range divelog.Dives.Dives {
-		c <- dive
+		add(dive)
+	}
+	return dives
+}
+
+// exportDives writes the dives and the dive sites they refer to to filename as Subsurface XML.
+func exportDives(filename string, divelog *subsurfacetypes.Divelog, dives []subsurfacetypes.Dive) error {
+	exportFile, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	subset := divelog.Subset(dives)
+	if err := subsurfacetypes.WriteDivelog(exportFile, &subset); err != nil {
+		exportFile.Close()
+		return err
+	}
+	return exportFile.Close()
+}
+
+// divesUntil returns the dives that started at or before t. Dives without a date are kept.
+func divesUntil(dives []subsurfacetypes.Dive, t time.Time) []subsurfacetypes.Dive {
+	var result []subsurfacetypes.Dive
+	for i := range dives {
+		if dives[i].HasDate() && dives[i].DateTime().After(t) {
+			continue
+		}
+		result = append(result, dives[i])
+	}
+	return result
+}
+
+// divesBetween returns the dives that started on the days from first to last, inclusive. A zero first or
+// last leaves that end of the range open. Dives without a date are left out.
+func divesBetween(dives []subsurfacetypes.Dive, first, last time.Time) []subsurfacetypes.Dive {
+	var result []subsurfacetypes.Dive
+	for i := range dives {
+		if !dives[i].HasDate() {
+			continue
+		}
+		date := dives[i].Date.Value
+		if !first.IsZero() && date.Before(first) || !last.IsZero() && date.After(last) {
+			continue
+		}
+		result = append(result, dives[i])
+	}
+	return result
+}
+
+// flagIsSet returns true if the flag was given on the command line.
+func flagIsSet(name string) bool {
+	set := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
+// divesFromComputer returns the dives whose primary dive computer model contains model, ignoring case.
+func divesFromComputer(dives []subsurfacetypes.Dive, model string) []subsurfacetypes.Dive {
+	model = strings.ToLower(strings.TrimSpace(model))
+	var result []subsurfacetypes.Dive
+	for i := range dives {
+		if strings.Contains(strings.ToLower(dives[i].PrimaryComputer().Model), model) {
+			result = append(result, dives[i])
+		}
+	}
+	return result
+}
+
+func main() {
+	flag.Parse()
+	if *quietFlag {
+		subsurfacetypes.Logger.SetOutput(ioutil.Discard)
+	}
+	var err error
+	enabledStats, err = parseStatTypes(*statsFlag)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		exit(1)
+	}
+	if *asOfFlag != "" {
+		asOf, err := time.Parse("2006-01-02", *asOfFlag)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			exit(1)
+		}
+		// The last instant of the day, so that the reference date itself is the as-of day everywhere.
+		referenceTime = asOf.AddDate(0, 0, 1).Add(-time.Nanosecond)
+	}
+	var fromDate, toDate time.Time
+	if *fromFlag != "" {
+		if fromDate, err = time.Parse("2006-01-02", *fromFlag); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			exit(1)
+		}
+	}
+	if *toFlag != "" {
+		if toDate, err = time.Parse("2006-01-02", *toFlag); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			exit(1)
+		}
+	}
+	if *formatFlag != "table" && *formatFlag != "jsonl" && *formatFlag != "prometheus" {
+		fmt.Fprintln(os.Stderr, "Invalid format", *formatFlag)
+		exit(1)
+	}
+	if *summaryFlag && *formatFlag != "table" {
+		fmt.Fprintln(os.Stderr, "-summary prints plain text and cannot be used with -format", *formatFlag)
+		exit(1)
+	}
+	if *groupByFlag != "" && (*stateFlag != "" || *summaryFlag || *formatFlag != "table") {
+		fmt.Fprintln(os.Stderr, "-group-by cannot be used with -state, -summary or a -format other than table")
+		exit(1)
+	}
+	// -list has its own sort fields, checked by listDives.
+	if !*listFlag {
+		if err := counter.CheckSortBy(*sortByFlag); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			exit(1)
+		}
+	}
+	switch *colorFlag {
+	case "auto":
+		if *outputFlag == "" && isTerminal(os.Stdout) {
+			counter.TableStyle = table.StyleColoredBright
+		}
+	case "always":
+		counter.TableStyle = table.StyleColoredBright
+	case "never":
+	default:
+		fmt.Fprintln(os.Stderr, "Invalid color", *colorFlag)
+		exit(1)
+	}
+	switch *unitsFlag {
+	case "metric":
+	case "imperial":
+		imperialUnits = true
+		slotters[stattype.SAC] = subsurfacetypes.SlotterFunc(subsurfacetypes.ImperialSacToSlot)
+	default:
+		fmt.Fprintln(os.Stderr, "Invalid units", *unitsFlag)
+		exit(1)
+	}
+	switch *timeUnitFlag {
+	case "days":
+		counter.DisplayTimeUnit = counter.Days
+	case "hours":
+		counter.DisplayTimeUnit = counter.Hours
+	default:
+		fmt.Fprintln(os.Stderr, "Invalid time unit", *timeUnitFlag)
+		exit(1)
+	}
+	for _, keyword := range strings.Split(*siteKeywordsFlag, ",") {
+		if keyword = strings.TrimSpace(keyword); keyword != "" {
+			siteKeywords = append(siteKeywords, keyword)
+		}
+	}
+	if *temperatureBandsFlag != "" {
+		var bounds []float64
+		for _, value := range strings.Split(*temperatureBandsFlag, ",") {
+			bound, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "Invalid temperature band", value)
+				exit(1)
+			}
+			bounds = append(bounds, bound)
+		}
+		if imperialUnits {
+			setTemperatureSlotter(subsurfacetypes.NewFahrenheitTemperatureSlotter(bounds...))
+		} else {
+			setTemperatureSlotter(subsurfacetypes.NewTemperatureSlotter(bounds...))
+		}
+	} else if imperialUnits {
+		setTemperatureSlotter(subsurfacetypes.NewFahrenheitTemperatureSlotter())
+	}
+	if *durationBandsFlag != "" {
+		var bounds []time.Duration
+		for _, value := range strings.Split(*durationBandsFlag, ",") {
+			minutes, err := strconv.Atoi(strings.TrimSpace(value))
+			if err != nil || minutes <= 0 {
+				fmt.Fprintln(os.Stderr, "Invalid duration band", value)
+				exit(1)
+			}
+			bounds = append(bounds, time.Duration(minutes)*time.Minute)
+		}
+		slotters[stattype.DiveLength] = subsurfacetypes.NewDurationSlotter(bounds...).Minutes()
+	}
+	if *slotterConfigFlag != "" {
+		configured, err := loadSlotterConfig(*slotterConfigFlag)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			exit(1)
+		}
+		for statType, slotter := range configured {
+			slotters[statType] = slotter
+		}
+	}
+	if *outputFlag != "" {
+		outputFile, err := os.Create(*outputFlag)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			exit(2)
+		}
+		closeOutput = func() {
+			if err := outputFile.Close(); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+			}
+		}
+		defer closeOutput()
+		output = outputFile
+	}
+	divelog := readAndUnmarshal(*filenameFlag)
+	for _, warning := range divelog.VersionWarnings() {
+		subsurfacetypes.Logger.Println(warning)
+	}
+	diveSites := processDiveSites(&divelog)
+	if *mergeSitesFlag {
+		mergeDiveSites(diveSites, *mergeSitesDistanceFlag)
+	}
+	var groupKey func(dive *subsurfacetypes.Dive) string
+	if *groupByFlag != "" {
+		if groupKey, err = groupKeys(*groupByFlag, &diveSites); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			exit(1)
+		}
+	}
+	allDives := collectDives(&divelog)
+	dives := allDives
+	if *asOfFlag != "" {
+		dives = divesUntil(dives, referenceTime)
+	}
+	if *fromFlag != "" || *toFlag != "" {
+		dives = divesBetween(dives, fromDate, toDate)
+	}
+	if *computerFlag != "" {
+		dives = divesFromComputer(dives, *computerFlag)
+	}
+	warnAboutDives(dives)
+	// Deferred, so that the summary is logged after every report, including -list and -validate-only.
+	defer func() {
+		if summary := subsurfacetypes.Warnings.Summary(); summary != "" {
+			subsurfacetypes.Logger.Println(summary)
+		}
+	}()
+	if *anonymizeFlag {
+		anonymizeDives(dives)
+	}
+	if *exportFlag != "" {
+		if err := exportDives(*exportFlag, &divelog, dives); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			exit(2)
+		}
+	}
+	if *validateOnlyFlag {
+		printParseSummary(&divelog, dives)
+		printValidationIssues(append(validateDives(dives, diveSites), numberGapIssues(allDives)...))
+		return
+	}
+	if *listFlag {
+		sortBy := ""
+		if flagIsSet("sort") {
+			sortBy = *sortByFlag
+		}
+		rows, err := listDives(dives, &diveSites, sortBy)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			exit(1)
+		}
+		printDiveList(rows)
+		return
+	}
+	if *validateFlag {
+		printValidationIssues(append(validateDives(dives, diveSites), numberGapIssues(allDives)...))
+	}
+	if *explainFlag {
+		printExplanations(dives)
+	}
+	// Filtered out dives still end the surface interval before the next dive.
+	surfaceIntervals = subsurfacetypes.SurfaceIntervals(allDives)
+	if *groupByFlag != "" {
+		// The grouped tables replace the usual statistics.
+		printGroupedStats(groupedStats(dives, &diveSites, groupKey))
+	} else if err := countDives(dives, &diveSites); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		// 130 is the usual exit code after an interrupt.
+		if errors.Is(err, context.Canceled) {
+			exit(130)
+		}
+		exit(1)
+	}
+	if *buddyPairsFlag > 0 {
+		printBuddyPairs(topBuddyPairs(dives, *buddyPairsFlag))
+	}
+	if *yearToDateFlag {
+		printYearToDate(divesYearToDate(dives, referenceTime, *compareYearsFlag))
+	}
+	if *timeseriesFlag {
+		printTimeseries(divesPerWeek(dives))
+	}
+	if *siteYearsFlag {
+		siteYears := diveSiteYears(dives, &diveSites)
+		printYearTable("Kohde", namesByName(siteYears), siteYears)
+	}
+	if *buddyYearsFlag {
+		buddies := buddyYears(dives)
+		printYearTable("Pari", namesByActiveYears(buddies), buddies)
+	}
+	if *depthSeriesFlag {
+		printDepthSeries(dives)
+	}
+	if *tripsFlag {
+		printTripSummaries(summarizeTrips(divelog.Dives.Trips, dives))
+	}
+	if *gasPerDepthFlag {
+		printGasPerDepthBand(gasPerDepthBand(dives))
+	}
+	if *totalDepthFlag {
+		fmt.Fprintf(output, "Syvyyksien summa %.1f m\n", totalMaxDepth(dives))
+	}
+	if *totalGasFlag {
+		if imperialUnits {
+			fmt.Fprintf(output, "Kaasua hengitetty yhteensä %.0f cuft\n", subsurfacetypes.LitersToCuft(totalGasLiters(dives)))
+		} else {
+			fmt.Fprintf(output, "Kaasua hengitetty yhteensä %.0f l\n", totalGasLiters(dives))
+		}
+	}
+	if *noFlyFlag {
+		fmt.Fprintln(output, decoDivesReport(dives, referenceTime))
+	}
+	if *longestGapFlag {
+		if gap, err := longestGap(dives); err != nil {
+			fmt.Fprintln(output, err)
+		} else {
+			fmt.Fprintln(output, gap)
+		}
+	}
+	if *goalFlag > 0 {
+		projection, err := projectGoal(dives, referenceTime, *goalFlag)
+		if err != nil {
+			fmt.Fprintln(output, err)
+		} else {
+			fmt.Fprintln(output, projection)
+		}
+	}
+	if *rollingFlag {
+		printRollingCounts(rollingCounts(dives))
+	}
+	if *bestMixFlag {
+		printRicherMixDives(richerMixDives(dives))
+	}
+	if *rbtThresholdFlag > 0 {
+		printLowRBTDives(lowRBTDives(dives, *rbtThresholdFlag))
 	}
-	close(c)
-	wg.Wait()
 }
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/ojarva/subsurface-statistics/counter"
+	"github.com/ojarva/subsurface-statistics/subsurfacetypes"
+)
+
+// rollingCount is the number of dives in the 12 months up to the end of a month.
+type rollingCount struct {
+	Month time.Time // First day of the month
+	Count int
+}
+
+// rollingCounts returns the trailing 12 month dive count at the end of every month from the first to
+// the last valid dive, oldest first. Months without dives are included. Dives without a date are skipped.
+func rollingCounts(dives []subsurfacetypes.Dive) []rollingCount {
+	var starts []time.Time
+	for i := range dives {
+		if dives[i].IsInvalid() || !dives[i].HasDate() {
+			continue
+		}
+		starts = append(starts, dives[i].DateTime())
+	}
+	if len(starts) == 0 {
+		return nil
+	}
+	sort.Slice(starts, func(i, j int) bool { return starts[i].Before(starts[j]) })
+	var counts []rollingCount
+	first, next := 0, 0
+	month := time.Date(starts[0].Year(), starts[0].Month(), 1, 0, 0, 0, 0, starts[0].Location())
+	for ; !month.After(starts[len(starts)-1]); month = month.AddDate(0, 1, 0) {
+		end := month.AddDate(0, 1, 0)
+		windowStart := end.AddDate(-1, 0, 0)
+		for next < len(starts) && starts[next].Before(end) {
+			next++
+		}
+		for first < next && starts[first].Before(windowStart) {
+			first++
+		}
+		counts = append(counts, rollingCount{month, next - first})
+	}
+	return counts
+}
+
+// printRollingCounts prints the rolling count of each month and the change from the previous month.
+func printRollingCounts(counts []rollingCount) {
+	t := table.NewWriter()
+	t.SetOutputMirror(output)
+	t.SetStyle(counter.TableStyle)
+	t.AppendHeader(table.Row{"Kuukausi", "Sukelluksia 12 kk", "Muutos"})
+	t.AppendSeparator()
+	previous := 0
+	for _, count := range counts {
+		t.AppendRow([]interface{}{count.Month.Format("2006-01"), count.Count, fmt.Sprintf("%+d", count.Count-previous)})
+		previous = count.Count
+	}
+	t.Render()
+}
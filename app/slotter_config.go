@@ -0,0 +1,49 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/ojarva/subsurface-statistics/stattype"
+	"github.com/ojarva/subsurface-statistics/subsurfacetypes"
+)
+
+// signedSlotValues are the statistics with numeric slots where zero and negative values are valid. For the
+// other statistics zero means the value is missing, so custom slots put it to "unknown" like the defaults.
+var signedSlotValues = map[stattype.StatType]bool{
+	stattype.Temperature:    true,
+	stattype.MinTemperature: true,
+	stattype.AirTemperature: true,
+}
+
+// loadSlotterConfig reads custom slots from a JSON file such as
+//
+//	{"MaxDepth": [{"upperBound": 18, "label": "OWD"}, {"upperBound": 30, "label": "AOWD"}, {"label": "deep"}]}
+//
+// Values are in the units the slotters map uses. Only statistics with numeric slots can be configured.
+func loadSlotterConfig(filename string) (map[stattype.StatType]subsurfacetypes.Slotter, error) {
+	content, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var config map[stattype.StatType][]subsurfacetypes.Band
+	if err := json.Unmarshal(content, &config); err != nil {
+		return nil, fmt.Errorf("invalid slotter config %s: %w", filename, err)
+	}
+	configured := map[stattype.StatType]subsurfacetypes.Slotter{}
+	for statType, bands := range config {
+		if _, ok := slotters[statType]; !ok {
+			return nil, fmt.Errorf("invalid slotter config %s: %s does not use numeric slots", filename, statType)
+		}
+		slotter, err := subsurfacetypes.NewBandSlotter(bands)
+		if err != nil {
+			return nil, fmt.Errorf("invalid slotter config %s: %s: %w", filename, statType, err)
+		}
+		if !signedSlotValues[statType] {
+			slotter = slotter.UnknownAtOrBelowZero()
+		}
+		configured[statType] = slotter
+	}
+	return configured, nil
+}
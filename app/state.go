@@ -0,0 +1,150 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"encoding/xml"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/ojarva/subsurface-statistics/subsurfacetypes"
+)
+
+// diveStats holds everything accumulated from the dives. It can be saved with -state and loaded on
+// the next run, so that only dives not seen before are added.
+type diveStats struct {
+	ReferenceTime time.Time // SinceLast and SinceFirst are relative to this
+	Stats         statsContainerMap
+	SeenDives     map[string]bool   // Identities of processed dives
+	Settings      map[string]string // Flags that change how dives are counted, see stateSettingFlags
+}
+
+// stateSettingFlags change how dives are counted. Statistics saved with -state can only be added to on
+// runs with the same values.
+var stateSettingFlags = []string{
+	"stats", "units", "temperature-bands", "duration-bands", "slotter-config", "no-dedup-cylinders",
+	"extradata-key", "extradata-step", "merge-sites", "merge-sites-distance", "exclude-buddy", "exclude-site",
+}
+
+// stateSettings returns the current values of stateSettingFlags. The slotter config is identified by
+// the checksum of its content, so that editing the file is noticed.
+func stateSettings() (map[string]string, error) {
+	settings := make(map[string]string, len(stateSettingFlags))
+	for _, name := range stateSettingFlags {
+		settings[name] = flag.Lookup(name).Value.String()
+	}
+	if filename := settings["slotter-config"]; filename != "" {
+		content, err := ioutil.ReadFile(filename)
+		if err != nil {
+			return nil, err
+		}
+		settings["slotter-config"] = fmt.Sprintf("sha256:%x", sha256.Sum256(content))
+	}
+	return settings, nil
+}
+
+func newDiveStats(referenceTime time.Time, settings map[string]string) *diveStats {
+	return &diveStats{
+		ReferenceTime: referenceTime,
+		Stats:         make(statsContainerMap),
+		SeenDives:     make(map[string]bool),
+		Settings:      settings,
+	}
+}
+
+// markSeen records the dive as processed. It returns false if the same dive, by Identity, was already
+// processed. Dives without an identity are matched by their content.
+func (ds *diveStats) markSeen(dive *subsurfacetypes.Dive) bool {
+	identity := dive.Identity()
+	if identity == "" {
+		content, err := xml.Marshal(dive)
+		if err != nil {
+			return true
+		}
+		identity = fmt.Sprintf("content sha256:%x", sha256.Sum256(content))
+	}
+	if ds.SeenDives[identity] {
+		return false
+	}
+	ds.SeenDives[identity] = true
+	return true
+}
+
+// checkSettings returns an error naming the first flag whose value differs between saved and current.
+func checkSettings(saved, current map[string]string) error {
+	if saved == nil {
+		return fmt.Errorf("saved statistics do not record the settings they were computed with; remove the state file to start over")
+	}
+	names := make([]string, 0, len(current))
+	for name := range current {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if saved[name] != current[name] {
+			return fmt.Errorf("saved statistics were computed with -%s=%q, not %q", name, saved[name], current[name])
+		}
+	}
+	return nil
+}
+
+// Save writes the statistics as JSON.
+func (ds *diveStats) Save(w io.Writer) error {
+	return json.NewEncoder(w).Encode(ds)
+}
+
+// Load replaces the statistics with ones saved earlier. Recency is moved from the saved reference time
+// to the current one. Statistics saved with different settings are not loaded.
+func (ds *diveStats) Load(r io.Reader) error {
+	var saved diveStats
+	if err := json.NewDecoder(r).Decode(&saved); err != nil {
+		return err
+	}
+	if err := checkSettings(saved.Settings, ds.Settings); err != nil {
+		return err
+	}
+	shift := ds.ReferenceTime.Sub(saved.ReferenceTime)
+	for _, stats := range saved.Stats {
+		stats.Shift(shift)
+	}
+	saved.ReferenceTime = ds.ReferenceTime
+	if saved.Stats == nil {
+		saved.Stats = make(statsContainerMap)
+	}
+	if saved.SeenDives == nil {
+		saved.SeenDives = make(map[string]bool)
+	}
+	*ds = saved
+	return nil
+}
+
+// loadStateFile loads saved statistics from filename. A missing file is not an error.
+func loadStateFile(filename string, ds *diveStats) error {
+	stateFile, err := os.Open(filename)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer stateFile.Close()
+	return ds.Load(stateFile)
+}
+
+// saveStateFile saves the statistics to filename.
+func saveStateFile(filename string, ds *diveStats) error {
+	stateFile, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	if err := ds.Save(stateFile); err != nil {
+		stateFile.Close()
+		return err
+	}
+	return stateFile.Close()
+}
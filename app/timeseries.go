@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/ojarva/subsurface-statistics/counter"
+	"github.com/ojarva/subsurface-statistics/subsurfacetypes"
+)
+
+// isoWeek identifies an ISO 8601 week.
+type isoWeek struct {
+	Year int
+	Week int
+}
+
+func newISOWeek(t time.Time) isoWeek {
+	year, week := t.ISOWeek()
+	return isoWeek{year, week}
+}
+
+func (w isoWeek) String() string {
+	return fmt.Sprintf("%d-W%02d", w.Year, w.Week)
+}
+
+func (w isoWeek) Before(other isoWeek) bool {
+	return w.Year < other.Year || w.Year == other.Year && w.Week < other.Week
+}
+
+// weekCount holds the number of dives done during a week.
+type weekCount struct {
+	Week  isoWeek
+	Dives int
+}
+
+// divesPerWeek counts valid dives per ISO week. The result is ordered by week and covers every week
+// from the first dive to the last, so weeks without dives are included with a zero count.
+func divesPerWeek(dives []subsurfacetypes.Dive) []weekCount {
+	counts := make(map[isoWeek]int)
+	var dates []time.Time
+	for i := range dives {
+		if dives[i].IsInvalid() || !dives[i].HasDate() {
+			continue
+		}
+		counts[newISOWeek(dives[i].Date.Value)]++
+		dates = append(dates, dives[i].Date.Value)
+	}
+	if len(dates) == 0 {
+		return nil
+	}
+	sort.Slice(dates, func(i, j int) bool { return dates[i].Before(dates[j]) })
+	last := newISOWeek(dates[len(dates)-1])
+	var result []weekCount
+	for day := dates[0]; !last.Before(newISOWeek(day)); day = day.AddDate(0, 0, 7) {
+		week := newISOWeek(day)
+		result = append(result, weekCount{week, counts[week]})
+	}
+	return result
+}
+
+func printTimeseries(weeks []weekCount) {
+	t := table.NewWriter()
+	t.SetOutputMirror(output)
+	t.SetStyle(counter.TableStyle)
+	t.AppendHeader(table.Row{"Viikko", "Sukelluksia", ""})
+	t.AppendSeparator()
+	for _, week := range weeks {
+		t.AppendRow([]interface{}{week.Week.String(), week.Dives, strings.Repeat("█", week.Dives)})
+	}
+	t.Render()
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"github.com/ojarva/subsurface-statistics/subsurfacetypes"
+)
+
+// totalMaxDepth sums the max depths of valid dives, roughly the total descent in meters.
+// Dives with unknown max depth are skipped.
+func totalMaxDepth(dives []subsurfacetypes.Dive) float64 {
+	total := 0.0
+	for i := range dives {
+		if dives[i].IsInvalid() {
+			continue
+		}
+		if depth := dives[i].Depth().Max.Value; depth > 0 {
+			total += depth
+		}
+	}
+	return total
+}
+
+// totalGasLiters sums the gas breathed from the cylinders of valid dives. Cylinders without size or
+// valid pressures are skipped.
+func totalGasLiters(dives []subsurfacetypes.Dive) float64 {
+	total := 0.0
+	for i := range dives {
+		if dives[i].IsInvalid() {
+			continue
+		}
+		for _, cylinder := range dives[i].Cylinders {
+			if used, err := cylinder.GasUsedLiters(); err == nil {
+				total += used
+			}
+		}
+	}
+	return total
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/ojarva/subsurface-statistics/counter"
+	"github.com/ojarva/subsurface-statistics/subsurfacetypes"
+)
+
+// tripSummary holds totals of the valid dives in a single trip.
+type tripSummary struct {
+	Location string
+	Dives    int
+	DiveTime time.Duration
+	MaxDepth float64
+	First    time.Time // Start of the first dated dive; zero if no dive has a date
+	Last     time.Time // Start of the last dated dive; zero if no dive has a date
+}
+
+// summarizeTrips returns a summary of each trip that has dives left in dives, in the order the trips are
+// listed. Dives are matched to trips by TripNumber, so that filtered out dives are not counted.
+func summarizeTrips(trips []subsurfacetypes.Trip, dives []subsurfacetypes.Dive) []tripSummary {
+	byTrip := make([]*tripSummary, len(trips))
+	for i := range dives {
+		dive := &dives[i]
+		if dive.TripNumber == 0 || dive.TripNumber > len(trips) || dive.IsInvalid() {
+			continue
+		}
+		summary := byTrip[dive.TripNumber-1]
+		if summary == nil {
+			summary = &tripSummary{Location: trips[dive.TripNumber-1].Location}
+			byTrip[dive.TripNumber-1] = summary
+		}
+		summary.Dives++
+		summary.DiveTime += dive.Duration()
+		if depth := dive.Depth().Max.Value; depth > summary.MaxDepth {
+			summary.MaxDepth = depth
+		}
+		if !dive.HasDate() {
+			continue
+		}
+		start := dive.DateTime()
+		if summary.First.IsZero() || start.Before(summary.First) {
+			summary.First = start
+		}
+		if start.After(summary.Last) {
+			summary.Last = start
+		}
+	}
+	var summaries []tripSummary
+	for _, summary := range byTrip {
+		if summary != nil {
+			summaries = append(summaries, *summary)
+		}
+	}
+	return summaries
+}
+
+func formatTripDate(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Format("2006-01-02")
+}
+
+func printTripSummaries(summaries []tripSummary) {
+	t := table.NewWriter()
+	t.SetOutputMirror(output)
+	t.SetStyle(counter.TableStyle)
+	t.AppendHeader(table.Row{"Paikka", "Alku", "Loppu", "Sukelluksia", "Sukellusaika", "Maksimisyvyys"})
+	t.AppendSeparator()
+	for _, summary := range summaries {
+		t.AppendRow([]interface{}{summary.Location, formatTripDate(summary.First), formatTripDate(summary.Last),
+			summary.Dives, fmt.Sprintf("%.1f h", summary.DiveTime.Hours()), fmt.Sprintf("%.1f m", summary.MaxDepth)})
+	}
+	t.Render()
+}
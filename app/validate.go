@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/ojarva/subsurface-statistics/subsurfacetypes"
+)
+
+// validationIssue describes a problem found in a single dive.
+type validationIssue struct {
+	DiveNumber string
+	Problem    string
+}
+
+func (vi validationIssue) String() string {
+	return fmt.Sprintf("Dive %s: %s", vi.DiveNumber, vi.Problem)
+}
+
+// validateDives checks the dives for common data entry errors, such as swapped cylinder pressures,
+// durations, depths and temperatures that cannot be parsed and references to dive sites that do not exist.
+func validateDives(dives []subsurfacetypes.Dive, diveSites diveSiteMap) []validationIssue {
+	var issues []validationIssue
+	for i := range dives {
+		if !diveSites.Exists(dives[i].DiveSiteID) {
+			issues = append(issues, validationIssue{dives[i].Number, fmt.Sprintf("unknown dive site %s", dives[i].DiveSiteID)})
+		}
+		if _, err := dives[i].ParseDuration(); err != nil && err != subsurfacetypes.ErrMissingValue {
+			issues = append(issues, validationIssue{dives[i].Number, fmt.Sprintf("duration: %v", err)})
+		}
+		for _, problem := range dives[i].ParseProblems() {
+			issues = append(issues, validationIssue{dives[i].Number, problem})
+		}
+		for _, cylinder := range dives[i].Cylinders {
+			if err := cylinder.ValidatePressures(); err != nil {
+				issues = append(issues, validationIssue{dives[i].Number, fmt.Sprintf("cylinder %q: %v", cylinder.Description, err)})
+			}
+		}
+	}
+	return issues
+}
+
+// numberGapIssues reports the gaps in dive numbering. It should be given all dives of the divelog, as
+// filters such as -computer leave gaps that are not errors.
+func numberGapIssues(dives []subsurfacetypes.Dive) []validationIssue {
+	var issues []validationIssue
+	for _, gap := range numberGaps(dives) {
+		issues = append(issues, validationIssue{gap.String(), "missing from the dive number sequence"})
+	}
+	return issues
+}
+
+// numberGap is a range of dive numbers missing from the divelog.
+type numberGap struct {
+	From int
+	To   int
+}
+
+func (g numberGap) String() string {
+	if g.From == g.To {
+		return strconv.Itoa(g.From)
+	}
+	return fmt.Sprintf("%d-%d", g.From, g.To)
+}
+
+// numberGaps returns the ranges of numbers missing between the smallest and the largest dive number,
+// which usually means a lost or unimported dive. Dives without a numeric number are skipped.
+func numberGaps(dives []subsurfacetypes.Dive) []numberGap {
+	var numbers []int
+	for i := range dives {
+		if number, err := dives[i].NumberInt(); err == nil {
+			numbers = append(numbers, number)
+		}
+	}
+	sort.Ints(numbers)
+	var gaps []numberGap
+	for i := 1; i < len(numbers); i++ {
+		if numbers[i]-numbers[i-1] > 1 {
+			gaps = append(gaps, numberGap{numbers[i-1] + 1, numbers[i] - 1})
+		}
+	}
+	return gaps
+}
+
+func printValidationIssues(issues []validationIssue) {
+	for _, issue := range issues {
+		fmt.Fprintln(output, issue)
+	}
+	fmt.Fprintln(output, "Virheitä", len(issues))
+}
+
+// printParseSummary prints the number of dives, dive sites and trips parsed from the divelog, and the
+// number of values that could not be parsed.
+func printParseSummary(divelog *subsurfacetypes.Divelog, dives []subsurfacetypes.Dive) {
+	fmt.Fprintln(output, "Sukelluksia", len(dives))
+	fmt.Fprintln(output, "Kohteita", len(divelog.Divesites.Site))
+	fmt.Fprintln(output, "Matkoja", len(divelog.Dives.Trips))
+	if summary := subsurfacetypes.Warnings.Summary(); summary != "" {
+		fmt.Fprintln(output, "Ohitettuja arvoja:", summary)
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/ojarva/subsurface-statistics/counter"
+	"github.com/ojarva/subsurface-statistics/subsurfacetypes"
+)
+
+// yearToDate holds the number of dives done in a year up to the same month and day as the reference date.
+type yearToDate struct {
+	Year  int
+	Dives int
+}
+
+// divesYearToDate counts dives from the start of the year up to and including the month and day of reference,
+// for the reference year and the given number of previous years. The reference year is first.
+func divesYearToDate(dives []subsurfacetypes.Dive, reference time.Time, previousYears int) []yearToDate {
+	result := make([]yearToDate, 0, previousYears+1)
+	for year := reference.Year(); year >= reference.Year()-previousYears; year-- {
+		start := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+		// February 29 is clamped to February 28 in years that are not leap years.
+		day := reference.Day()
+		if lastDay := time.Date(year, reference.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day(); day > lastDay {
+			day = lastDay
+		}
+		end := time.Date(year, reference.Month(), day, 0, 0, 0, 0, time.UTC).AddDate(0, 0, 1)
+		count := 0
+		for i := range dives {
+			if dives[i].IsInvalid() || !dives[i].HasDate() {
+				continue
+			}
+			date := dives[i].Date.Value
+			if !date.Before(start) && date.Before(end) {
+				count++
+			}
+		}
+		result = append(result, yearToDate{year, count})
+	}
+	return result
+}
+
+func printYearToDate(years []yearToDate) {
+	t := table.NewWriter()
+	t.SetOutputMirror(output)
+	t.SetStyle(counter.TableStyle)
+	t.AppendHeader(table.Row{"Vuosi", "Sukelluksia tähän päivään mennessä"})
+	t.AppendSeparator()
+	for _, year := range years {
+		t.AppendRow([]interface{}{year.Year, year.Dives})
+	}
+	t.Render()
+}
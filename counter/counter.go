@@ -2,18 +2,38 @@ package counter
 
 import (
 	"fmt"
-	"os"
+	"io"
 	"sort"
+	"strings"
 	"time"
 
 	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/jedib0t/go-pretty/v6/text"
 )
 
 type lastCounterStat struct {
-	Name       string
-	Count      int
-	SinceLast  time.Duration
-	SinceFirst time.Duration
+	Name          string
+	Count         int
+	SinceLast     time.Duration
+	SinceFirst    time.Duration
+	Dated         bool           // SinceLast and SinceFirst are only valid if at least one occurrence had a date
+	Order         int            // Natural order of the entry, used by the "order" sort key
+	FirstDate     time.Time      // Date of the earliest occurrence; zero if no occurrence had a date
+	Variants      map[string]int // Number of occurrences per spelling of the name
+	Values        int            // Number of occurrences added with a value
+	Min           float64        // Smallest value; only valid if Values > 0
+	Max           float64        // Largest value; only valid if Values > 0
+	Sum           float64        // Sum of the values, used for the average
+	Unit          string         // Unit of the values, such as "m"; shown after them
+	TotalDuration time.Duration  // Sum of the durations of the occurrences, used by the "time" sort key
+}
+
+// Mean returns the average of the values added to the entry, or 0 if there are none.
+func (stat *lastCounterStat) Mean() float64 {
+	if stat.Values == 0 {
+		return 0
+	}
+	return stat.Sum / float64(stat.Values)
 }
 
 // statSorter joins a SortBy function and a slice of LastCounterStat to be sorted.
@@ -33,8 +53,15 @@ func (s *statSorter) Swap(i, j int) {
 }
 
 // Less is part of sort.Interface. It is implemented by calling the "by" closure in the sorter.
+// Ties are broken by name so that the order does not depend on map iteration order.
 func (s *statSorter) Less(i, j int) bool {
-	return s.by(&s.stats[i], &s.stats[j])
+	if s.by(&s.stats[i], &s.stats[j]) {
+		return true
+	}
+	if s.by(&s.stats[j], &s.stats[i]) {
+		return false
+	}
+	return s.stats[i].Name < s.stats[j].Name
 }
 
 // LastCounterStats holds information regarding last occurrence of specified event
@@ -42,10 +69,22 @@ type LastCounterStats map[string]*lastCounterStat
 
 // LastCounter keeps track of occurrences and last time something happened
 type lastCounter interface {
-	Add(name string, timeSince *time.Duration)
+	Add(name string, occurrence Occurrence)
 	PrintStats()
 }
 
+// Occurrence is a single dive counted in the statistics.
+type Occurrence struct {
+	TimeSince *time.Duration // Time since the dive; nil for dives without a date
+	Date      time.Time      // Start time of the dive; zero for dives without a date
+	Duration  time.Duration  // Length of the dive; zero if unknown
+}
+
+// Summary holds optional totals printed below the statistics table.
+type Summary struct {
+	DiveTime time.Duration // Total dive time of the counted dives; omitted when zero
+}
+
 // SortBy implements selecting a correct field for sorting.
 type SortBy func(d1, d2 *lastCounterStat) bool
 
@@ -53,6 +92,60 @@ func formatDurationToDays(duration time.Duration) string {
 	return fmt.Sprintf("%.0f", duration.Hours()/24.0)
 }
 
+// TimeUnit selects the unit of the time since first and last occurrence in tables.
+type TimeUnit int
+
+// Supported time units.
+const (
+	Days TimeUnit = iota
+	Hours
+)
+
+// DisplayTimeUnit is the unit PrintStats uses for time since first and last occurrence.
+var DisplayTimeUnit = Days
+
+// formatSince formats duration in DisplayTimeUnit.
+func formatSince(duration time.Duration) string {
+	if DisplayTimeUnit == Hours {
+		return fmt.Sprintf("%.0f", duration.Hours())
+	}
+	return formatDurationToDays(duration)
+}
+
+// sinceHeaders returns the column headers for time since last and first occurrence in DisplayTimeUnit.
+func sinceHeaders() (string, string) {
+	if DisplayTimeUnit == Hours {
+		return "Edellinen tuntia sitten", "Ensimmäinen tuntia sitten"
+	}
+	return "Edellinen päivää sitten", "Ensimmäinen päivää sitten"
+}
+
+// TableStyle is the style of the tables printed by PrintStats. Reports printed elsewhere should use it too.
+var TableStyle = table.StyleDefault
+
+// Reverse returns a SortBy that sorts in the opposite order.
+func (sortBy SortBy) Reverse() SortBy {
+	return func(d1, d2 *lastCounterStat) bool {
+		return sortBy(d2, d1)
+	}
+}
+
+// ChainSortBy returns a SortBy that compares with each of the functions in turn until one of them
+// tells the entries apart.
+func ChainSortBy(sorters ...SortBy) SortBy {
+	return func(d1, d2 *lastCounterStat) bool {
+		for _, less := range sorters {
+			if less(d1, d2) {
+				return true
+			}
+			if less(d2, d1) {
+				return false
+			}
+		}
+		return false
+	}
+}
+
 // Sort is a method on the function type, SortBy, that sorts the argument slice according to the function.
 func (sortBy SortBy) Sort(stats []lastCounterStat) {
 	ps := &statSorter{
@@ -62,61 +155,290 @@ func (sortBy SortBy) Sort(stats []lastCounterStat) {
 	sort.Sort(ps)
 }
 
-// Add adds a new instance to the counter.
-func (p LastCounterStats) Add(name string, timeSince *time.Duration) {
-	_, ok := p[name]
+// Add adds a new instance to the counter. Occurrences without a date are counted but do not change
+// SinceLast, SinceFirst or FirstDate.
+func (p LastCounterStats) Add(name string, occurrence Occurrence) {
+	p.AddVariant(name, name, occurrence)
+}
+
+// AddOrdered adds a new instance to the counter and sets the natural order of the entry. This is used
+// for entries, such as ratings, where neither name nor count gives a meaningful order.
+func (p LastCounterStats) AddOrdered(name string, order int, occurrence Occurrence) {
+	p.Add(name, occurrence)
+	p[name].Order = order
+}
+
+// AddVariant adds a new instance to the counter under key, recording name as one spelling of the entry.
+// The most common spelling is used as the displayed name.
+func (p LastCounterStats) AddVariant(key, name string, occurrence Occurrence) {
+	p.addVariant(key, name)
+	if timeSince := occurrence.TimeSince; timeSince != nil {
+		if !p[key].Dated {
+			p[key].SinceLast = *timeSince
+			p[key].SinceFirst = *timeSince
+			p[key].Dated = true
+		}
+		if *timeSince < p[key].SinceLast {
+			p[key].SinceLast = *timeSince
+		}
+		if *timeSince > p[key].SinceFirst {
+			p[key].SinceFirst = *timeSince
+		}
+	}
+	if !occurrence.Date.IsZero() && (p[key].FirstDate.IsZero() || occurrence.Date.Before(p[key].FirstDate)) {
+		p[key].FirstDate = occurrence.Date
+	}
+	p[key].Count++
+	p[key].TotalDuration += occurrence.Duration
+
+}
+
+// AddValue adds a new instance to the counter and records value, such as the depth or duration of the
+// dive, so that the smallest, largest and average value of the entry can be shown. unit is shown after
+// the values.
+func (p LastCounterStats) AddValue(name string, value float64, unit string, occurrence Occurrence) {
+	p.Add(name, occurrence)
+	stat := p[name]
+	stat.Unit = unit
+	if stat.Values == 0 || value < stat.Min {
+		stat.Min = value
+	}
+	if stat.Values == 0 || value > stat.Max {
+		stat.Max = value
+	}
+	stat.Sum += value
+	stat.Values++
+}
+
+// hasValues returns true if any entry has values added with AddValue.
+func (p LastCounterStats) hasValues() bool {
+	for _, stat := range p {
+		if stat.Values > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// addVariant creates the entry for key if needed and updates its displayed name to the most common spelling.
+func (p LastCounterStats) addVariant(key, name string) {
+	stat, ok := p[key]
 	if !ok {
-		p[name] = &lastCounterStat{name, 0, *timeSince, *timeSince}
+		stat = &lastCounterStat{Name: name}
+		p[key] = stat
 	}
-	if *timeSince < p[name].SinceLast {
-		p[name].SinceLast = *timeSince
+	if stat.Variants == nil {
+		stat.Variants = map[string]int{}
 	}
-	if *timeSince > p[name].SinceFirst {
-		p[name].SinceFirst = *timeSince
+	stat.Variants[name]++
+	if stat.Variants[name] > stat.Variants[stat.Name] {
+		stat.Name = name
 	}
-	p[name].Count++
+}
 
+// Shift adds d to the time since first and last occurrence of every entry, e.g. when statistics
+// saved earlier are used with a later reference time.
+func (p LastCounterStats) Shift(d time.Duration) {
+	for _, stat := range p {
+		if stat.Dated {
+			stat.SinceLast += d
+			stat.SinceFirst += d
+		}
+	}
 }
 
-// PrintStats prints tabulated statistics to stdout
-func (p LastCounterStats) PrintStats(sortBy string) {
-	t := table.NewWriter()
-	t.SetOutputMirror(os.Stdout)
-	t.AppendHeader(table.Row{"#", "Nimi", "Kertoja", "Edellinen päivää sitten", "Ensimmäinen päivää sitten"})
-	t.AppendSeparator()
+// TotalCount returns the sum of counts over all entries.
+func (p LastCounterStats) TotalCount() int {
+	total := 0
+	for _, stat := range p {
+		total += stat.Count
+	}
+	return total
+}
+
+// TotalDuration returns the sum of the durations of the occurrences over all entries.
+func (p LastCounterStats) TotalDuration() time.Duration {
+	var total time.Duration
+	for _, stat := range p {
+		total += stat.TotalDuration
+	}
+	return total
+}
+
+// Counts returns the count of each entry by its key.
+func (p LastCounterStats) Counts() map[string]int {
+	counts := make(map[string]int, len(p))
+	for key, stat := range p {
+		counts[key] = stat.Count
+	}
+	return counts
+}
+
+// Names returns the displayed name of each entry by its key.
+func (p LastCounterStats) Names() map[string]string {
+	names := make(map[string]string, len(p))
+	for key, stat := range p {
+		names[key] = stat.Name
+	}
+	return names
+}
+
+// monthIndex returns the number of the month named by name, or 13 for anything that is not a month name.
+func monthIndex(name string) int {
+	for month := time.January; month <= time.December; month++ {
+		if month.String() == name {
+			return int(month)
+		}
+	}
+	return 13
+}
+
+// staleScore tells how overdue an entry is for a revisit: days since the last occurrence multiplied by
+// the number of occurrences. Frequent entries that have not been seen lately score the highest.
+func staleScore(stat *lastCounterStat) float64 {
+	return stat.SinceLast.Hours() / 24.0 * float64(stat.Count)
+}
+
+// formatValue formats a value recorded with AddValue, or "-" if the entry has no values.
+func formatValue(stat *lastCounterStat, value float64) string {
+	if stat.Values == 0 {
+		return "-"
+	}
+	if stat.Unit == "" {
+		return fmt.Sprintf("%.1f", value)
+	}
+	return fmt.Sprintf("%.1f %s", value, stat.Unit)
+}
+
+func formatDurationToHours(duration time.Duration) string {
+	return fmt.Sprintf("%.1f h", duration.Hours())
+}
+
+// sortFields are the fields accepted in sortBy, such as "count" or "-sinceLast".
+var sortFields = map[string]SortBy{
+	"name": func(s1, s2 *lastCounterStat) bool {
+		return s1.Name < s2.Name
+	},
+	"count": func(s1, s2 *lastCounterStat) bool {
+		return s1.Count < s2.Count
+	},
+	"sinceFirst": func(s1, s2 *lastCounterStat) bool {
+		return s1.SinceFirst < s2.SinceFirst
+	},
+	"sinceLast": func(s1, s2 *lastCounterStat) bool {
+		return s1.SinceLast < s2.SinceLast
+	},
+	"month": func(s1, s2 *lastCounterStat) bool {
+		return monthIndex(s1.Name) < monthIndex(s2.Name)
+	},
+	"stale": func(s1, s2 *lastCounterStat) bool {
+		return staleScore(s1) < staleScore(s2)
+	},
+	"order": func(s1, s2 *lastCounterStat) bool {
+		return s1.Order < s2.Order
+	},
+	"time": func(s1, s2 *lastCounterStat) bool {
+		return s1.TotalDuration < s2.TotalDuration
+	},
+}
+
+// datedFields are the sort fields computed from the dates of the occurrences.
+var datedFields = map[string]bool{"sinceFirst": true, "sinceLast": true, "stale": true}
+
+// datedFirst sorts entries with a dated occurrence before the entries without one.
+func datedFirst(s1, s2 *lastCounterStat) bool {
+	return s1.Dated && !s2.Dated
+}
+
+// CheckSortBy returns an error if sortBy has a field that is not in sortFields.
+func CheckSortBy(sortBy string) error {
+	for _, key := range strings.Split(sortBy, ",") {
+		key = strings.TrimSpace(key)
+		if _, ok := sortFields[strings.TrimPrefix(key, "-")]; !ok {
+			return fmt.Errorf("invalid sort field %q", key)
+		}
+	}
+	return nil
+}
+
+// sorted returns the entries sorted by sortBy, a comma-separated list of sort keys applied in order.
+// A key prefixed with "-" sorts in descending order. Entries without dates sort after the dated ones in
+// either order when sorting by datedFields.
+func (p LastCounterStats) sorted(sortBy string) []lastCounterStat {
 	sl := make([]lastCounterStat, len(p))
 	i := 0
 	for _, stat := range p {
 		sl[i] = *stat
 		i++
 	}
-	nameSort := func(s1, s2 *lastCounterStat) bool {
-		return s1.Name < s2.Name
-	}
-	countSort := func(s1, s2 *lastCounterStat) bool {
-		return s1.Count < s2.Count
-	}
-	sinceFirstSort := func(s1, s2 *lastCounterStat) bool {
-		return s1.SinceFirst < s2.SinceFirst
+	var chain []SortBy
+	for _, key := range strings.Split(sortBy, ",") {
+		key = strings.TrimSpace(key)
+		field := strings.TrimPrefix(key, "-")
+		sorter, ok := sortFields[field]
+		if !ok {
+			// Fields are checked with CheckSortBy before printing.
+			continue
+		}
+		if datedFields[field] {
+			chain = append(chain, datedFirst)
+		}
+		if strings.HasPrefix(key, "-") {
+			sorter = sorter.Reverse()
+		}
+		chain = append(chain, sorter)
 	}
-	sinceLastSort := func(s1, s2 *lastCounterStat) bool {
-		return s1.SinceLast < s2.SinceLast
+	ChainSortBy(chain...).Sort(sl)
+	return sl
+}
+
+// PrintStats prints tabulated statistics to w, sorted by sortBy. If summary is not nil, totals are printed after the table.
+func (p LastCounterStats) PrintStats(w io.Writer, sortBy string, summary *Summary) {
+	t := table.NewWriter()
+	t.SetOutputMirror(w)
+	t.SetStyle(TableStyle)
+	sinceLastHeader, sinceFirstHeader := sinceHeaders()
+	header := table.Row{"#", "Nimi", "Kertoja", sinceLastHeader, sinceFirstHeader, "Ensimmäinen kerta"}
+	columnConfigs := []table.ColumnConfig{
+		{Number: 1, Align: text.AlignRight},
+		{Number: 3, Align: text.AlignRight},
+		{Number: 4, Align: text.AlignRight},
+		{Number: 5, Align: text.AlignRight},
 	}
-	switch sortBy {
-	case "name":
-		SortBy(nameSort).Sort(sl)
-	case "count":
-		SortBy(countSort).Sort(sl)
-	case "sinceFirst":
-		SortBy(sinceFirstSort).Sort(sl)
-	case "sinceLast":
-		SortBy(sinceLastSort).Sort(sl)
-	default:
-		fmt.Println("Invalid sort flag", sortBy, ". Showing entries in random order.")
+	withValues := p.hasValues()
+	if withValues {
+		header = append(header, "Min", "Max", "Keskiarvo")
+		columnConfigs = append(columnConfigs,
+			table.ColumnConfig{Number: 7, Align: text.AlignRight},
+			table.ColumnConfig{Number: 8, Align: text.AlignRight},
+			table.ColumnConfig{Number: 9, Align: text.AlignRight},
+		)
 	}
+	t.AppendHeader(header)
+	t.SetColumnConfigs(columnConfigs)
+	t.AppendSeparator()
+	sl := p.sorted(sortBy)
 	for i, stat := range sl {
-		t.AppendRow([]interface{}{i + 1, stat.Name, stat.Count, formatDurationToDays(stat.SinceLast), formatDurationToDays(stat.SinceFirst)})
+		sinceLast, sinceFirst := "-", "-"
+		if stat.Dated {
+			sinceLast, sinceFirst = formatSince(stat.SinceLast), formatSince(stat.SinceFirst)
+		}
+		firstDate := "-"
+		if !stat.FirstDate.IsZero() {
+			firstDate = stat.FirstDate.Format("2006-01-02")
+		}
+		row := table.Row{i + 1, stat.Name, stat.Count, sinceLast, sinceFirst, firstDate}
+		if withValues {
+			row = append(row, formatValue(&stat, stat.Min), formatValue(&stat, stat.Max), formatValue(&stat, stat.Mean()))
+		}
+		t.AppendRow(row)
 	}
 	t.Render()
-	fmt.Println("Yhteensä", len(p))
+	fmt.Fprintln(w, "Yhteensä", len(p))
+	if summary != nil {
+		fmt.Fprintln(w, "Sukelluksia yhteensä", p.TotalCount())
+		if summary.DiveTime > 0 {
+			fmt.Fprintln(w, "Sukellusaika yhteensä", formatDurationToHours(summary.DiveTime))
+		}
+	}
 }
@@ -0,0 +1,54 @@
+package counter
+
+import (
+	"encoding/json"
+	"io"
+	"math"
+)
+
+// jsonStat is a single entry in JSON Lines output.
+type jsonStat struct {
+	Category       string   `json:"category"`
+	Name           string   `json:"name"`
+	Count          int      `json:"count"`
+	SinceLastDays  *int     `json:"sinceLastDays,omitempty"`
+	SinceFirstDays *int     `json:"sinceFirstDays,omitempty"`
+	FirstDate      string   `json:"firstDate,omitempty"`
+	Min            *float64 `json:"min,omitempty"`
+	Max            *float64 `json:"max,omitempty"`
+	Mean           *float64 `json:"mean,omitempty"`
+	Unit           string   `json:"unit,omitempty"`
+}
+
+func sinceDays(stat *lastCounterStat) (sinceLast, sinceFirst *int) {
+	if !stat.Dated {
+		return nil, nil
+	}
+	last := int(math.Round(stat.SinceLast.Hours() / 24.0))
+	first := int(math.Round(stat.SinceFirst.Hours() / 24.0))
+	return &last, &first
+}
+
+// WriteJSONLines writes each entry as a separate JSON object on its own line (JSON Lines), sorted by sortBy.
+// Entries are written one at a time, so downstream tools can process the output incrementally.
+func (p LastCounterStats) WriteJSONLines(w io.Writer, category string, sortBy string) error {
+	encoder := json.NewEncoder(w)
+	for _, stat := range p.sorted(sortBy) {
+		sinceLast, sinceFirst := sinceDays(&stat)
+		var firstDate string
+		if !stat.FirstDate.IsZero() {
+			firstDate = stat.FirstDate.Format("2006-01-02")
+		}
+		entry := jsonStat{Category: category, Name: stat.Name, Count: stat.Count, SinceLastDays: sinceLast, SinceFirstDays: sinceFirst, FirstDate: firstDate}
+		if stat.Values > 0 {
+			min, max, mean := stat.Min, stat.Max, stat.Mean()
+			entry.Min, entry.Max, entry.Mean = &min, &max, &mean
+			entry.Unit = stat.Unit
+		}
+		err := encoder.Encode(entry)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package counter
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// PrometheusMetric is the name of the metric written by WritePrometheus.
+const PrometheusMetric = "subsurface_dives_total"
+
+// prometheusLabelEscaper escapes label values as required by the Prometheus text exposition format.
+var prometheusLabelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// WritePrometheusHeader writes the HELP and TYPE lines of the metric. It should be written once, before
+// the entries of any category.
+func WritePrometheusHeader(w io.Writer) error {
+	_, err := fmt.Fprintf(w, "# HELP %s Number of dives per statistic entry.\n# TYPE %s counter\n", PrometheusMetric, PrometheusMetric)
+	return err
+}
+
+// WritePrometheus writes each entry as a sample in Prometheus text exposition format, sorted by sortBy,
+// e.g. subsurface_dives_total{category="Buddies",name="Alice"} 12
+func (p LastCounterStats) WritePrometheus(w io.Writer, category string, sortBy string) error {
+	for _, stat := range p.sorted(sortBy) {
+		_, err := fmt.Fprintf(w, "%s{category=\"%s\",name=\"%s\"} %d\n", PrometheusMetric,
+			prometheusLabelEscaper.Replace(category), prometheusLabelEscaper.Replace(stat.Name), stat.Count)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
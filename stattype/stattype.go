@@ -0,0 +1,67 @@
+// Package stattype lists the statistic categories computed from a divelog.
+package stattype
+
+import "fmt"
+
+// StatType is a category of statistics, such as buddies or dive sites.
+type StatType int
+
+//go:generate stringer -type=StatType
+const (
+	DiveLength StatType = iota
+	Buddies
+	Cylinders
+	MeanDepth
+	MaxDepth
+	Temperature
+	DiveSite
+	TagStat
+	Month
+	GasSwitches
+	MinTemperature
+	Deco
+	DiveComputer
+	AirTemperature
+	Rating
+	SiteKeyword
+	DepthProfile
+	SurfaceInterval
+	DecoModel
+	ReverseProfile
+	SAC
+	Weekday
+	BottomGas
+	EAD
+	CylinderMaterial
+	GearSet
+	SafetyStop
+	ExtraData
+	GroupSize
+	AscentRate
+	GFHigh
+)
+
+// AllStatTypes returns every statistic type in declaration order.
+func AllStatTypes() []StatType {
+	statTypes := make([]StatType, len(_StatType_index)-1)
+	for i := range statTypes {
+		statTypes[i] = StatType(i)
+	}
+	return statTypes
+}
+
+// MarshalText encodes the statistic type as its name, so that saved statistics do not depend on the order of the constants.
+func (i StatType) MarshalText() ([]byte, error) {
+	return []byte(i.String()), nil
+}
+
+// UnmarshalText decodes a statistic type from its name.
+func (i *StatType) UnmarshalText(text []byte) error {
+	for _, statType := range AllStatTypes() {
+		if statType.String() == string(text) {
+			*i = statType
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown statistic type %q", text)
+}
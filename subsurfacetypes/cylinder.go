@@ -0,0 +1,105 @@
+package subsurfacetypes
+
+import (
+	"fmt"
+	"strings"
+)
+
+// GasVolumeLiters returns the amount of gas the cylinder holds when filled to its working pressure,
+// i.e. water volume multiplied by working pressure.
+func (c Cylinder) GasVolumeLiters() (float64, error) {
+	size, err := parseVolumeLiters(c.Size)
+	if err != nil {
+		return 0, fmt.Errorf("cylinder size: %w", err)
+	}
+	workPressure, err := parsePressureBar(c.WorkPressure)
+	if err != nil {
+		return 0, fmt.Errorf("cylinder working pressure: %w", err)
+	}
+	return size * workPressure, nil
+}
+
+// GasUsedLiters returns the amount of gas breathed from the cylinder, i.e. water volume multiplied by
+// the pressure drop. Cylinders without size or valid start and end pressures return an error.
+func (c Cylinder) GasUsedLiters() (float64, error) {
+	size, err := parseVolumeLiters(c.Size)
+	if err != nil {
+		return 0, fmt.Errorf("cylinder size: %w", err)
+	}
+	if strings.TrimSpace(c.Start) == "" || strings.TrimSpace(c.End) == "" {
+		return 0, ErrMissingValue
+	}
+	if err := c.ValidatePressures(); err != nil {
+		return 0, err
+	}
+	start, _ := parsePressureBar(c.Start)
+	end, _ := parsePressureBar(c.End)
+	return size * (start - end), nil
+}
+
+// ValidatePressures checks that the start pressure is above the end pressure. Cylinders without any
+// pressure information are not checked.
+func (c Cylinder) ValidatePressures() error {
+	if strings.TrimSpace(c.Start) == "" && strings.TrimSpace(c.End) == "" {
+		return nil
+	}
+	start, err := parsePressureBar(c.Start)
+	if err != nil {
+		return fmt.Errorf("start pressure: %w", err)
+	}
+	end, err := parsePressureBar(c.End)
+	if err != nil {
+		return fmt.Errorf("end pressure: %w", err)
+	}
+	if start == 0 || end == 0 {
+		return fmt.Errorf("zero pressure (start %s, end %s)", c.Start, c.End)
+	}
+	if end >= start {
+		return fmt.Errorf("end pressure %s is not below start pressure %s", c.End, c.Start)
+	}
+	return nil
+}
+
+// SwitchDepthMeters returns the depth in meters where the diver switches to the cylinder. Only deco
+// gases have a switch depth; ErrMissingValue is returned for cylinders without one.
+func (c Cylinder) SwitchDepthMeters() (float64, error) {
+	return parseDepthMeters(c.Depth)
+}
+
+// IsDecoGas returns true if the cylinder has a switch depth, i.e. it is carried for decompression
+// rather than breathed at the bottom.
+func (c Cylinder) IsDecoGas() bool {
+	depth, err := c.SwitchDepthMeters()
+	return err == nil && depth > 0
+}
+
+// Keywords in cylinder descriptions that tell the cylinder material. Descriptions are matched
+// case-insensitively, word by word, and a keyword may be followed by a size, so "AL80" is aluminum.
+var (
+	aluminumKeywords = []string{"al", "alu", "aluminum", "aluminium"}
+	steelKeywords    = []string{"steel", "d", "hp", "lp", "fx", "x7", "fe"}
+)
+
+// Material classifies the cylinder as "aluminum" or "steel" based on keywords in its description,
+// such as "AL80", "HP100" or "D12 steel". Anything else is "unknown".
+func (c Cylinder) Material() string {
+	for _, word := range words(c.Description) {
+		switch {
+		case matchesKeyword(word, aluminumKeywords):
+			return "aluminum"
+		case matchesKeyword(word, steelKeywords):
+			return "steel"
+		}
+	}
+	return "unknown"
+}
+
+// matchesKeyword returns true if word is one of keywords, possibly followed by a number.
+func matchesKeyword(word string, keywords []string) bool {
+	for _, keyword := range keywords {
+		if rest := strings.TrimPrefix(word, keyword); rest != word && strings.TrimLeft(rest, "0123456789.") == "" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,151 @@
+package subsurfacetypes
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// words splits text to lower case words.
+func words(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// containsWords returns true if needle appears in haystack as consecutive whole words.
+func containsWords(haystack, needle []string) bool {
+	if len(needle) == 0 {
+		return false
+	}
+	for i := 0; i+len(needle) <= len(haystack); i++ {
+		match := true
+		for j := range needle {
+			if haystack[i+j] != needle[j] {
+				match = false
+				break
+			}
+		}
+		if match {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchKeywords returns the keywords that appear in the notes or description of the site. Matching is
+// case-insensitive and only whole words match, so "wall" does not match "walls". A keyword given
+// several times, in any case, is returned once.
+func (s Divesite) MatchKeywords(keywords []string) []string {
+	text := words(s.Notes + " " + s.Description)
+	var matches []string
+	seen := map[string]bool{}
+	for _, keyword := range keywords {
+		keywordWords := words(keyword)
+		key := strings.Join(keywordWords, " ")
+		if seen[key] || !containsWords(text, keywordWords) {
+			continue
+		}
+		seen[key] = true
+		matches = append(matches, keyword)
+	}
+	return matches
+}
+
+const earthRadiusMeters = 6371000.0
+
+// dmsPattern matches a coordinate in degrees and minutes, optionally with seconds, such as
+// "N 60° 10.194'" or "E 24° 56' 18.2\"".
+var dmsPattern = regexp.MustCompile(`([NSEW])\s*(\d+(?:\.\d+)?)°\s*(?:(\d+(?:\.\d+)?)'\s*)?(?:(\d+(?:\.\d+)?)"\s*)?`)
+
+// Coordinates parses the GPS location of the site to decimal degrees. Both decimal degrees, e.g.
+// "60.169900 24.938400", and degrees with minutes, e.g. "N 60° 10.194' E 24° 56.304'", are supported.
+// Latitudes beyond ±90 and longitudes beyond ±180 degrees are rejected.
+func (s Divesite) Coordinates() (lat, lon float64, err error) {
+	if strings.ContainsRune(s.GPS, '°') {
+		lat, lon, err = parseDMSCoordinates(s.GPS)
+	} else {
+		lat, lon, err = parseDecimalCoordinates(s.GPS)
+	}
+	if err != nil {
+		return 0, 0, err
+	}
+	// The comparisons also reject NaN.
+	if !(math.Abs(lat) <= 90) || !(math.Abs(lon) <= 180) {
+		return 0, 0, fmt.Errorf("unrecognized GPS format %q", s.GPS)
+	}
+	return lat, lon, nil
+}
+
+// parseDecimalCoordinates parses a latitude followed by a longitude in decimal degrees.
+func parseDecimalCoordinates(gps string) (lat, lon float64, err error) {
+	fields := strings.Fields(gps)
+	if len(fields) != 2 {
+		return 0, 0, fmt.Errorf("unrecognized GPS format %q", gps)
+	}
+	lat, err = strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("unrecognized GPS format %q: %w", gps, err)
+	}
+	lon, err = strconv.ParseFloat(fields[1], 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("unrecognized GPS format %q: %w", gps, err)
+	}
+	return lat, lon, nil
+}
+
+// parseDMSCoordinates parses a latitude followed by a longitude in degrees, minutes and seconds.
+func parseDMSCoordinates(gps string) (lat, lon float64, err error) {
+	value := strings.TrimSpace(gps)
+	matches := dmsPattern.FindAllStringSubmatchIndex(value, -1)
+	if len(matches) != 2 || matches[0][0] != 0 || matches[0][1] != matches[1][0] || matches[1][1] != len(value) {
+		return 0, 0, fmt.Errorf("unrecognized GPS format %q", gps)
+	}
+	var degrees [2]float64
+	for i, match := range matches {
+		hemisphere := value[match[2]:match[3]]
+		if i == 0 && hemisphere != "N" && hemisphere != "S" || i == 1 && hemisphere != "E" && hemisphere != "W" {
+			return 0, 0, fmt.Errorf("unrecognized GPS format %q", gps)
+		}
+		for group, scale := range []float64{1, 60, 3600} {
+			start, end := match[4+2*group], match[5+2*group]
+			if start < 0 {
+				continue
+			}
+			number, _ := strconv.ParseFloat(value[start:end], 64)
+			if group > 0 && number >= 60 {
+				return 0, 0, fmt.Errorf("unrecognized GPS format %q", gps)
+			}
+			degrees[i] += number / scale
+		}
+		if hemisphere == "S" || hemisphere == "W" {
+			degrees[i] = -degrees[i]
+		}
+	}
+	return degrees[0], degrees[1], nil
+}
+
+// DistanceMeters returns the great-circle distance between two sites. Sites without valid coordinates return an error.
+func (s Divesite) DistanceMeters(other Divesite) (float64, error) {
+	lat1, lon1, err := s.Coordinates()
+	if err != nil {
+		return 0, err
+	}
+	lat2, lon2, err := other.Coordinates()
+	if err != nil {
+		return 0, err
+	}
+	toRadians := func(degrees float64) float64 { return degrees * math.Pi / 180 }
+	dLat := toRadians(lat2 - lat1)
+	dLon := toRadians(lon2 - lon1)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(toRadians(lat1))*math.Cos(toRadians(lat2))*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusMeters * math.Asin(math.Sqrt(a)), nil
+}
+
+// NormalizedName returns the site name in lower case with extra whitespace removed, for comparing names.
+func (s Divesite) NormalizedName() string {
+	return strings.ToLower(strings.Join(strings.Fields(s.Name), " "))
+}
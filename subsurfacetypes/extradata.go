@@ -0,0 +1,76 @@
+package subsurfacetypes
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ExtraDataMap returns the extra data as a map. If a key appears more than once, the last value wins.
+func (dc DiveComputer) ExtraDataMap() map[string]string {
+	values := make(map[string]string, len(dc.ExtraData))
+	for _, extraData := range dc.ExtraData {
+		values[extraData.Key] = extraData.Value
+	}
+	return values
+}
+
+// ExtraValue returns the value of an extra data key. If the key appears more than once, the last value wins,
+// consistent with ExtraDataMap.
+func (dc DiveComputer) ExtraValue(key string) (string, bool) {
+	for i := len(dc.ExtraData) - 1; i >= 0; i-- {
+		if dc.ExtraData[i].Key == key {
+			return dc.ExtraData[i].Value, true
+		}
+	}
+	return "", false
+}
+
+// ExtraNumber returns the numeric value of an extra data key, ignoring a unit after the number, such as
+// "3.9 V" or "85%". The second return value is false if the key is missing or the value is not a number.
+func (dc DiveComputer) ExtraNumber(key string) (float64, bool) {
+	value, ok := dc.ExtraValue(key)
+	if !ok {
+		return 0, false
+	}
+	number, _, err := splitValueAndUnit(value)
+	if err != nil {
+		return 0, false
+	}
+	return number, true
+}
+
+// DecoModelKey is the extra data key dive computers use for the decompression model.
+const DecoModelKey = "Deco model"
+
+// DecoModel returns the decompression model recorded by the dive computer, or an empty string.
+func (d *Dive) DecoModel() string {
+	decoModel, _ := d.PrimaryComputer().ExtraValue(DecoModelKey)
+	return strings.TrimSpace(decoModel)
+}
+
+// Extra data keys some dive computers use for gradient factors instead of including them in the deco model.
+const (
+	GFLowKey  = "GF Low"
+	GFHighKey = "GF High"
+)
+
+var gradientFactorsPattern = regexp.MustCompile(`(?i)GF\s*(\d+)\s*/\s*(\d+)`)
+
+// GradientFactors returns the gradient factors in percent, read from a deco model such as "GF 30/70" or from
+// separate GF Low and GF High keys. The third return value is false if the dive computer did not record them.
+func (d *Dive) GradientFactors() (low, high int, ok bool) {
+	if match := gradientFactorsPattern.FindStringSubmatch(d.DecoModel()); match != nil {
+		low, _ = strconv.Atoi(match[1])
+		high, _ = strconv.Atoi(match[2])
+		return low, high, true
+	}
+	diveComputer := d.PrimaryComputer()
+	lowValue, lowFound := diveComputer.ExtraNumber(GFLowKey)
+	highValue, highFound := diveComputer.ExtraNumber(GFHighKey)
+	if !lowFound || !highFound {
+		return 0, 0, false
+	}
+	return int(math.Round(lowValue)), int(math.Round(highValue)), true
+}
@@ -0,0 +1,201 @@
+package subsurfacetypes
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Subsurface event types for gas changes. Type 25 encodes helium in the upper 16 bits of the value.
+const (
+	gasChangeEventType  = "11"
+	gasChange2EventType = "25"
+)
+
+// GasMix is a breathing gas. O2 and He are fractions (0-1); the rest is nitrogen.
+type GasMix struct {
+	O2 float64
+	He float64
+}
+
+// Air is the default gas when a cylinder has no mix information.
+var Air = GasMix{O2: 0.21}
+
+func (g GasMix) String() string {
+	o2 := int(math.Round(g.O2 * 100))
+	he := int(math.Round(g.He * 100))
+	switch {
+	case he > 0:
+		return fmt.Sprintf("%d/%d", o2, he)
+	case o2 == 21:
+		return "air"
+	case o2 == 100:
+		return "oxygen"
+	default:
+		return fmt.Sprintf("EAN%d", o2)
+	}
+}
+
+// parsePercent parses values such as "32.0%" to fractions.
+func parsePercent(value string) (float64, bool) {
+	value = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(value), "%"))
+	if value == "" {
+		return 0, false
+	}
+	percent, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, false
+	}
+	return percent / 100, true
+}
+
+// GasMix returns the gas in the cylinder. Cylinders without O2 information are assumed to contain air.
+func (c Cylinder) GasMix() GasMix {
+	o2, ok := parsePercent(c.O2)
+	if !ok || o2 == 0 {
+		o2 = Air.O2
+	}
+	he, _ := parsePercent(c.He)
+	return GasMix{O2: o2, He: he}
+}
+
+// IsGasChange returns true if the event is a gas switch.
+func (e DiveEvent) IsGasChange() bool {
+	return e.Type == gasChangeEventType || e.Type == gasChange2EventType || strings.EqualFold(e.Name, "gaschange")
+}
+
+// gasMix resolves the gas switched to, preferring the referenced cylinder over the encoded event value.
+func (e DiveEvent) gasMix(cylinders []Cylinder) (GasMix, bool) {
+	if index, err := strconv.Atoi(strings.TrimSpace(e.Cylinder)); err == nil && index >= 0 && index < len(cylinders) {
+		return cylinders[index].GasMix(), true
+	}
+	value, err := strconv.Atoi(strings.TrimSpace(e.Value))
+	if err != nil || value <= 0 {
+		return GasMix{}, false
+	}
+	return GasMix{O2: float64(value&0xffff) / 100, He: float64(value>>16) / 100}, true
+}
+
+// GasChanges returns the gases switched to during the dive, in order. Switches to the gas already
+// in use (such as the initial gas change Subsurface records at the start of a dive) and switches
+// to gases that cannot be resolved are left out.
+func (d *Dive) GasChanges() []GasMix {
+	var changes []GasMix
+	current := d.BottomGas()
+	for _, event := range d.PrimaryComputer().Events {
+		if !event.IsGasChange() {
+			continue
+		}
+		gas, ok := event.gasMix(d.Cylinders)
+		if !ok || gas == current {
+			continue
+		}
+		changes = append(changes, gas)
+		current = gas
+	}
+	return changes
+}
+
+// Limits and approximations used in gas calculations.
+const (
+	MaxBottomPPO2   = 1.4  // Maximum partial pressure of oxygen (bar) used for MOD
+	metersPerBar    = 10.0 // Meters of seawater per bar of pressure
+	surfacePressure = 1.0  // Surface pressure in bar
+)
+
+// ambientPressure returns absolute pressure in bar at the given depth in meters.
+func ambientPressure(depth float64) float64 {
+	return surfacePressure + depth/metersPerBar
+}
+
+// depthAt returns the depth in meters for an absolute pressure in bar.
+func depthAt(pressure float64) float64 {
+	return (pressure - surfacePressure) * metersPerBar
+}
+
+// MOD returns the maximum operating depth of the gas in meters at the given oxygen partial pressure.
+func (g GasMix) MOD(ppO2 float64) float64 {
+	if g.O2 <= 0 {
+		return 0
+	}
+	return depthAt(ppO2 / g.O2)
+}
+
+// END returns the equivalent narcotic depth in meters when breathing the gas at depth. Oxygen is
+// considered as narcotic as nitrogen, so only helium reduces narcosis.
+func (g GasMix) END(depth float64) float64 {
+	return depthAt(ambientPressure(depth) * (1 - g.He))
+}
+
+// nitrogenInAir is the fraction of nitrogen in air, used for EAD.
+const nitrogenInAir = 0.79
+
+// IsNitrox returns true for oxygen enriched mixes without helium.
+func (g GasMix) IsNitrox() bool {
+	return g.He == 0 && math.Round(g.O2*100) > math.Round(Air.O2*100)
+}
+
+// Classification returns "trimix" for mixes with helium, "nitrox" for oxygen enriched mixes and "air" otherwise.
+func (g GasMix) Classification() string {
+	switch {
+	case g.He > 0:
+		return "trimix"
+	case g.IsNitrox():
+		return "nitrox"
+	default:
+		return "air"
+	}
+}
+
+// EAD returns the equivalent air depth in meters when breathing the gas at depth, i.e. the depth
+// where air has the same nitrogen partial pressure.
+func (g GasMix) EAD(depth float64) float64 {
+	return depthAt(ambientPressure(depth) * (1 - g.O2) / nitrogenInAir)
+}
+
+// BottomGas returns the gas in the first cylinder that is not a deco gas. If every cylinder has a
+// switch depth, the first cylinder is used. Dives without cylinders are assumed to be on air.
+func (d *Dive) BottomGas() GasMix {
+	if len(d.Cylinders) == 0 {
+		return Air
+	}
+	for _, cylinder := range d.Cylinders {
+		if !cylinder.IsDecoGas() {
+			return cylinder.GasMix()
+		}
+	}
+	return d.Cylinders[0].GasMix()
+}
+
+// MOD returns the maximum operating depth in meters of the bottom gas at 1.4 bar ppO2.
+func (d *Dive) MOD() float64 {
+	return d.BottomGas().MOD(MaxBottomPPO2)
+}
+
+// END returns the equivalent narcotic depth in meters of the bottom gas at maxDepth.
+func (d *Dive) END(maxDepth float64) float64 {
+	return d.BottomGas().END(maxDepth)
+}
+
+// EAD returns the equivalent air depth at the maximum depth of the dive. It is only valid for
+// nitrox dives with a known maximum depth; air and trimix dives are not valid.
+func (d *Dive) EAD() (float64, bool) {
+	gas := d.BottomGas()
+	maxDepth := d.Depth().Max.Value
+	if !gas.IsNitrox() || maxDepth <= 0 {
+		return 0, false
+	}
+	return gas.EAD(maxDepth), true
+}
+
+// BestMixO2 returns the richest oxygen fraction usable at the maximum depth of the dive at 1.4 bar ppO2,
+// rounded down to a whole percent. It is only valid for dives with a known maximum depth.
+func (d *Dive) BestMixO2() (float64, bool) {
+	maxDepth := d.Depth().Max.Value
+	if maxDepth <= 0 {
+		return 0, false
+	}
+	o2 := math.Floor(MaxBottomPPO2/ambientPressure(maxDepth)*100) / 100
+	return math.Min(o2, 1), true
+}
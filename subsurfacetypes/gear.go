@@ -0,0 +1,44 @@
+package subsurfacetypes
+
+import (
+	"sort"
+	"strings"
+)
+
+// TotalWeightKg returns the sum of all weights carried on the dive in kilograms. Weights that cannot
+// be parsed are skipped.
+func (d *Dive) TotalWeightKg() float64 {
+	total := 0.0
+	for _, weightSystem := range d.WeightSystem {
+		if weight, err := parseWeightKg(weightSystem.Weight); err == nil {
+			total += weight
+		}
+	}
+	return total
+}
+
+// GearSet describes the gear configuration of the dive as "suit / cylinder sizes / weight band",
+// e.g. "drysuit / 12.0 l / <8kg". Cylinder sizes are deduplicated and sorted, and missing parts are "-",
+// so dives with the same gear always get the same description.
+func (d *Dive) GearSet() string {
+	suit := strings.ToLower(strings.Join(strings.Fields(d.Suit), " "))
+	if suit == "" {
+		suit = "-"
+	}
+	seen := map[string]bool{}
+	var sizes []string
+	for _, cylinder := range d.Cylinders {
+		size := strings.TrimSpace(cylinder.Size)
+		if size == "" || seen[size] {
+			continue
+		}
+		seen[size] = true
+		sizes = append(sizes, size)
+	}
+	sort.Strings(sizes)
+	cylinders := strings.Join(sizes, " + ")
+	if cylinders == "" {
+		cylinders = "-"
+	}
+	return strings.Join([]string{suit, cylinders, WeightToSlot(d.TotalWeightKg())}, " / ")
+}
@@ -0,0 +1,71 @@
+package subsurfacetypes
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Logger receives warnings about values that could not be parsed. Warnings go to stderr so that
+// they do not get mixed with the statistics; use Logger.SetOutput(ioutil.Discard) to silence them.
+var Logger = log.New(os.Stderr, "", 0)
+
+// Kinds of values counted by Warnings.
+const (
+	DepthWarning       = "depth values"
+	TemperatureWarning = "temperatures"
+	DurationWarning    = "durations"
+)
+
+// WarningCounter counts values that could not be parsed, by kind.
+type WarningCounter struct {
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+// Warnings counts all values skipped while parsing.
+var Warnings = &WarningCounter{}
+
+// Add logs a warning to Logger and counts it under kind.
+func (w *WarningCounter) Add(kind string, v ...interface{}) {
+	w.mu.Lock()
+	if w.counts == nil {
+		w.counts = make(map[string]int)
+	}
+	w.counts[kind]++
+	w.mu.Unlock()
+	Logger.Println(v...)
+}
+
+// Counts returns a copy of the number of warnings per kind.
+func (w *WarningCounter) Counts() map[string]int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	counts := make(map[string]int, len(w.counts))
+	for kind, count := range w.counts {
+		counts[kind] = count
+	}
+	return counts
+}
+
+// Summary returns a one line summary such as "12 depth values, 3 temperatures skipped", or an empty
+// string if there were no warnings.
+func (w *WarningCounter) Summary() string {
+	counts := w.Counts()
+	if len(counts) == 0 {
+		return ""
+	}
+	kinds := make([]string, 0, len(counts))
+	for kind := range counts {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	parts := make([]string, len(kinds))
+	for i, kind := range kinds {
+		parts[i] = fmt.Sprintf("%d %s", counts[kind], kind)
+	}
+	return strings.Join(parts, ", ") + " skipped"
+}
@@ -0,0 +1,169 @@
+package subsurfacetypes
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+var utf8BOM = []byte("\xef\xbb\xbf")
+
+// trimXMLPrefix removes a byte order mark and whitespace, which some tools, mostly on Windows, write
+// before the XML declaration.
+func trimXMLPrefix(rawXMLValue []byte) []byte {
+	return bytes.TrimLeftFunc(bytes.TrimPrefix(rawXMLValue, utf8BOM), unicode.IsSpace)
+}
+
+// ParseDivelog reads and parses a Subsurface XML divelog.
+func ParseDivelog(r io.Reader) (*Divelog, error) {
+	rawXMLValue, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	rawXMLValue = trimXMLPrefix(rawXMLValue)
+	var divelog Divelog
+	err = xml.Unmarshal(rawXMLValue, &divelog)
+	if err != nil {
+		return nil, err
+	}
+	return &divelog, nil
+}
+
+// NormalizeDiveSiteID makes dive site UUIDs comparable. Subsurface writes UUIDs as hex, so case is not significant.
+func NormalizeDiveSiteID(id string) string {
+	return strings.ToLower(strings.Join(strings.Fields(id), ""))
+}
+
+// Subset returns a copy of the divelog that contains only the given dives and the dive sites they
+// refer to. Dives are matched to the trips of the divelog by Identity, so trips with at least one of
+// the dives are kept; the rest of the dives are listed directly under dives.
+func (d *Divelog) Subset(dives []Dive) Divelog {
+	referenced := make(map[string]bool)
+	selected := make(map[string]int)
+	for i := range dives {
+		referenced[NormalizeDiveSiteID(dives[i].DiveSiteID)] = true
+		if identity := dives[i].Identity(); identity != "" {
+			selected[identity] = i
+		}
+	}
+	subset := Divelog{Program: d.Program, Version: d.Version, Settings: d.Settings}
+	for _, site := range d.Divesites.Site {
+		if referenced[NormalizeDiveSiteID(site.UUID)] {
+			subset.Divesites.Site = append(subset.Divesites.Site, site)
+		}
+	}
+	inTrip := make(map[int]bool)
+	for _, trip := range d.Dives.Trips {
+		tripDives := trip.Dives
+		trip.Dives = nil
+		for _, dive := range tripDives {
+			i, ok := selected[dive.Identity()]
+			if !ok || inTrip[i] {
+				continue
+			}
+			inTrip[i] = true
+			trip.Dives = append(trip.Dives, dives[i])
+		}
+		if len(trip.Dives) > 0 {
+			subset.Dives.Trips = append(subset.Dives.Trips, trip)
+		}
+	}
+	for i := range dives {
+		if !inTrip[i] {
+			subset.Dives.Dives = append(subset.Dives.Dives, dives[i])
+		}
+	}
+	return subset
+}
+
+// WriteDivelog writes the divelog as Subsurface XML.
+func WriteDivelog(w io.Writer, divelog *Divelog) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(divelog); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// ParseDiveFragments reads a directory of XML fragments, each holding one or more dive, site or trip
+// elements, and assembles them into a single divelog. The elements may be wrapped in containers such as
+// dives or a whole divelog. Files are read in name order; files that do not end with .xml or .ssrf are
+// skipped. Subdirectories are not read.
+func ParseDiveFragments(dir string) (*Divelog, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var divelog Divelog
+	for _, file := range files {
+		extension := strings.ToLower(filepath.Ext(file.Name()))
+		if file.IsDir() || (extension != ".xml" && extension != ".ssrf") {
+			continue
+		}
+		path := filepath.Join(dir, file.Name())
+		fragment, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		err = parseFragment(fragment, &divelog)
+		fragment.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	return &divelog, nil
+}
+
+// parseFragment adds the top level dive, site and trip elements read from r to divelog. Other elements
+// are skipped.
+func parseFragment(r io.Reader, divelog *Divelog) error {
+	rawXMLValue, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	decoder := xml.NewDecoder(bytes.NewReader(trimXMLPrefix(rawXMLValue)))
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		switch start.Name.Local {
+		case "dive":
+			var dive Dive
+			err = decoder.DecodeElement(&dive, &start)
+			divelog.Dives.Dives = append(divelog.Dives.Dives, dive)
+		case "site":
+			var site Divesite
+			err = decoder.DecodeElement(&site, &start)
+			divelog.Divesites.Site = append(divelog.Divesites.Site, site)
+		case "trip":
+			var trip Trip
+			err = decoder.DecodeElement(&trip, &start)
+			divelog.Dives.Trips = append(divelog.Dives.Trips, trip)
+		default:
+			// Containers such as divelog, dives and divesites are descended into.
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
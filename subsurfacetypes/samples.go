@@ -0,0 +1,283 @@
+package subsurfacetypes
+
+import "time"
+
+// DecoInfo summarizes the decompression obligation of a dive.
+type DecoInfo struct {
+	InDeco     bool          // The dive had a decompression obligation at some point
+	TimeInDeco time.Duration // Total time with a decompression obligation, not just the time at the stops
+}
+
+// WaterTemperature returns the parsed water temperature of the sample. Samples without a temperature are not valid.
+func (s DiveSample) WaterTemperature() Temperature {
+	temperature, _ := parseTemperature(s.Temperature)
+	return temperature
+}
+
+// WaterTemperatureRange returns the lowest and the highest water temperature recorded in the samples.
+// When no sample has a temperature, both fall back to the water temperature of the dive.
+func (d *Dive) WaterTemperatureRange() (min, max Temperature) {
+	diveComputer := d.PrimaryComputer()
+	for _, sample := range diveComputer.Samples {
+		temperature := sample.WaterTemperature()
+		if !temperature.Valid {
+			continue
+		}
+		if !min.Valid || temperature.Value < min.Value {
+			min = temperature
+		}
+		if !max.Valid || temperature.Value > max.Value {
+			max = temperature
+		}
+	}
+	if !min.Valid {
+		water := d.WaterTemperature()
+		return water, water
+	}
+	return min, max
+}
+
+// TimeOffset returns the time of the sample from the start of the dive.
+func (s DiveSample) TimeOffset() (time.Duration, error) {
+	return parseMinutesSeconds(s.Time)
+}
+
+// Deco determines whether the dive went into deco and how long the obligation lasted. Subsurface only
+// records in_deco and stopdepth when they change, so the state is carried over to the following samples.
+// A sample is in deco when in_deco is set or the stop depth is below the surface.
+func (d *Dive) Deco() DecoInfo {
+	var info DecoInfo
+	inDeco := false
+	stopDepth := 0.0
+	var previousTime time.Duration
+	for i, sample := range d.PrimaryComputer().Samples {
+		sampleTime, err := sample.TimeOffset()
+		if err != nil {
+			continue
+		}
+		if inDeco && i > 0 {
+			info.TimeInDeco += sampleTime - previousTime
+		}
+		previousTime = sampleTime
+		switch sample.InDeco {
+		case "1":
+			inDeco = true
+		case "0":
+			inDeco = false
+		}
+		if depth, err := parseDepthMeters(sample.StopDepth); err == nil {
+			stopDepth = depth
+		}
+		inDeco = inDeco || stopDepth > 0
+		if inDeco {
+			info.InDeco = true
+		}
+	}
+	return info
+}
+
+// MinRBT returns the lowest remaining bottom time reported by the dive computer. The second return
+// value is false if no sample has RBT.
+func (d *Dive) MinRBT() (time.Duration, bool) {
+	var minRBT time.Duration
+	found := false
+	for _, sample := range d.PrimaryComputer().Samples {
+		rbt, err := parseMinutesSeconds(sample.RBT)
+		if err != nil {
+			continue
+		}
+		if !found || rbt < minRBT {
+			minRBT = rbt
+			found = true
+		}
+	}
+	return minRBT, found
+}
+
+// MinProfileSamples is the number of samples needed for analysing the shape of a dive profile.
+const MinProfileSamples = 3
+
+// DepthMeters returns the depth of the sample in meters.
+func (s DiveSample) DepthMeters() (float64, error) {
+	return parseDepthMeters(s.Depth)
+}
+
+// profilePoint is a sample with parsed time and depth.
+type profilePoint struct {
+	Time  time.Duration
+	Depth float64
+}
+
+// profile returns the samples that have both time and depth, in recorded order.
+func (d *Dive) profile() []profilePoint {
+	var points []profilePoint
+	for _, sample := range d.PrimaryComputer().Samples {
+		sampleTime, err := sample.TimeOffset()
+		if err != nil {
+			continue
+		}
+		depth, err := sample.DepthMeters()
+		if err != nil {
+			continue
+		}
+		points = append(points, profilePoint{sampleTime, depth})
+	}
+	return points
+}
+
+// HasProfile returns true if the dive has enough samples for analysing the shape of the profile.
+func (d *Dive) HasProfile() bool {
+	return len(d.profile()) >= MinProfileSamples
+}
+
+// ReverseProfile returns true if the deepest point of the dive is in the second half of the dive.
+// Dives with less than MinProfileSamples samples return false.
+func (d *Dive) ReverseProfile() bool {
+	points := d.profile()
+	if len(points) < MinProfileSamples {
+		return false
+	}
+	deepest := points[0]
+	for _, point := range points[1:] {
+		if point.Depth > deepest.Depth {
+			deepest = point
+		}
+	}
+	return deepest.Time > points[len(points)-1].Time/2
+}
+
+// SafetyStop describes a safety stop: at least Duration spent between MinDepth and MaxDepth meters
+// during the ascent, on dives deeper than RequiredBelow meters.
+type SafetyStop struct {
+	MinDepth      float64
+	MaxDepth      float64
+	Duration      time.Duration
+	RequiredBelow float64
+}
+
+// DefaultSafetyStop is the common recommendation of 3 minutes at 3-6 meters on dives deeper than 10 meters.
+var DefaultSafetyStop = SafetyStop{MinDepth: 3, MaxDepth: 6, Duration: 3 * time.Minute, RequiredBelow: 10}
+
+// SafetyStopRequired returns true if the dive went deeper than stop.RequiredBelow. Dives without enough samples
+// return false.
+func (d *Dive) SafetyStopRequired(stop SafetyStop) bool {
+	points := d.profile()
+	if len(points) < MinProfileSamples {
+		return false
+	}
+	for _, point := range points {
+		if point.Depth > stop.RequiredBelow {
+			return true
+		}
+	}
+	return false
+}
+
+// HasSafetyStop returns true if the diver spent at least stop.Duration in the stop depth window after
+// the deepest point of the dive. Time between two samples counts if both samples are in the window.
+func (d *Dive) HasSafetyStop(stop SafetyStop) bool {
+	points := d.profile()
+	if len(points) < MinProfileSamples {
+		return false
+	}
+	deepest := 0
+	for i, point := range points {
+		if point.Depth > points[deepest].Depth {
+			deepest = i
+		}
+	}
+	inWindow := func(point profilePoint) bool {
+		return point.Depth >= stop.MinDepth && point.Depth <= stop.MaxDepth
+	}
+	var stopTime time.Duration
+	for i := deepest + 1; i < len(points); i++ {
+		if inWindow(points[i-1]) && inWindow(points[i]) {
+			stopTime += points[i].Time - points[i-1].Time
+		}
+	}
+	return stopTime >= stop.Duration
+}
+
+// DepthPoint is the depth at a whole minute of the dive.
+type DepthPoint struct {
+	Minute int
+	Depth  float64
+}
+
+// DepthPerMinute resamples the profile to one point per minute from the start of the dive to the last
+// sample, interpolating linearly between samples. The dive is assumed to start at the surface. Dives
+// without samples return nil.
+func (d *Dive) DepthPerMinute() []DepthPoint {
+	points := d.profile()
+	if len(points) == 0 {
+		return nil
+	}
+	if points[0].Time > 0 {
+		points = append([]profilePoint{{0, 0}}, points...)
+	}
+	if len(points) == 1 {
+		return []DepthPoint{{0, points[0].Depth}}
+	}
+	last := points[len(points)-1].Time
+	var series []DepthPoint
+	next := 1
+	for minute := 0; time.Duration(minute)*time.Minute <= last; minute++ {
+		t := time.Duration(minute) * time.Minute
+		for next < len(points)-1 && points[next].Time < t {
+			next++
+		}
+		before, after := points[next-1], points[next]
+		depth := after.Depth
+		if span := after.Time - before.Time; span > 0 && t < after.Time {
+			depth = before.Depth + (after.Depth-before.Depth)*float64(t-before.Time)/float64(span)
+		}
+		series = append(series, DepthPoint{minute, depth})
+	}
+	return series
+}
+
+// Parameters for finding the final ascent. Samples shallower than surfaceDepth are at the surface, and a
+// level part of the profile is a stop if it lasts at least minStopDuration.
+const (
+	surfaceDepth    = 0.5
+	minStopDuration = time.Minute
+)
+
+// FinalAscentRate returns the mean rate of the final ascent in meters per minute. The final ascent starts
+// from the end of the last stop, or from the deepest point if there was no stop, and ends when the diver
+// reaches the surface; samples after that are ignored. Short level parts during the ascent are not stops.
+// The second return value is false for dives without enough samples or without an ascent at the end.
+func (d *Dive) FinalAscentRate() (float64, bool) {
+	points := d.profile()
+	if len(points) < MinProfileSamples {
+		return 0, false
+	}
+	end := len(points) - 1
+	for end > 0 && points[end].Depth <= surfaceDepth && points[end-1].Depth <= surfaceDepth {
+		end--
+	}
+	start := end
+	for start > 0 {
+		previous := points[start-1]
+		if previous.Depth > points[start].Depth {
+			start--
+			continue
+		}
+		if previous.Depth < points[start].Depth {
+			break
+		}
+		level := start - 1
+		for level > 0 && points[level-1].Depth == points[start].Depth {
+			level--
+		}
+		if points[start].Time-points[level].Time >= minStopDuration {
+			break
+		}
+		start = level
+	}
+	duration := points[end].Time - points[start].Time
+	if start == end || duration <= 0 || points[start].Depth <= points[end].Depth {
+		return 0, false
+	}
+	return (points[start].Depth - points[end].Depth) / duration.Minutes(), true
+}
@@ -1,37 +1,104 @@
 package subsurfacetypes
 
-import "time"
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
 
-func DurationToSlot(duration time.Duration) string {
+// Slotter buckets a numeric value, such as a depth in meters, into a labelled slot.
+type Slotter interface {
+	Slot(value float64) string
+}
+
+// SlotterFunc adapts a function such as MaxDepthToSlot to a Slotter.
+type SlotterFunc func(value float64) string
+
+// Slot calls f(value).
+func (f SlotterFunc) Slot(value float64) string {
+	return f(value)
+}
+
+// Default slotters for values that are not configurable.
+var (
+	MaxDepthSlotter   Slotter = SlotterFunc(MaxDepthToSlot)
+	MeanDepthSlotter  Slotter = SlotterFunc(MeanDepthToSlot)
+	EADSlotter        Slotter = SlotterFunc(EADToSlot)
+	DepthRatioSlotter Slotter = SlotterFunc(DepthRatioToSlot)
+	SacSlotter        Slotter = SlotterFunc(SacToSlot)
+	AscentRateSlotter Slotter = SlotterFunc(AscentRateToSlot)
+)
+
+// DefaultDurationBounds are the upper bounds of the duration bands used by DurationToSlot.
+var DefaultDurationBounds = []time.Duration{
+	10 * time.Minute, 20 * time.Minute, 30 * time.Minute, 40 * time.Minute, 50 * time.Minute,
+	60 * time.Minute, 70 * time.Minute, 80 * time.Minute, 90 * time.Minute,
+}
+
+// DurationSlotter buckets dive durations into bands with configurable upper bounds. Zero duration is "unknown".
+type DurationSlotter struct {
+	bounds []time.Duration
+}
+
+// NewDurationSlotter returns a slotter with the given upper bounds, e.g. 2, 5 and 10 minutes.
+// Bounds may be given in any order. Without bounds, DefaultDurationBounds are used.
+func NewDurationSlotter(bounds ...time.Duration) DurationSlotter {
+	if len(bounds) == 0 {
+		bounds = DefaultDurationBounds
+	}
+	sorted := append([]time.Duration(nil), bounds...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return DurationSlotter{bounds: sorted}
+}
+
+// formatDurationLabel formats bounds as "45min", "1h" or "1h10min".
+func formatDurationLabel(duration time.Duration) string {
+	minutes := int(duration.Minutes())
 	switch {
-	case duration == time.Duration(0):
-		return "unknown"
-	case duration < time.Duration(10*time.Minute):
-		return "<10min"
-	case duration < time.Duration(20*time.Minute):
-		return "<20min"
-	case duration < time.Duration(30*time.Minute):
-		return "<30min"
-	case duration < time.Duration(40*time.Minute):
-		return "<40min"
-	case duration < time.Duration(50*time.Minute):
-		return "<50min"
-	case duration < time.Duration(60*time.Minute):
-		return "<1h"
-	case duration < time.Duration(70*time.Minute):
-		return "<1h10min"
-	case duration < time.Duration(80*time.Minute):
-		return "<1h20min"
-	case duration < time.Duration(90*time.Minute):
-		return "<1h30min"
+	case minutes < 60:
+		return fmt.Sprintf("%dmin", minutes)
+	case minutes%60 == 0:
+		return fmt.Sprintf("%dh", minutes/60)
 	default:
-		return ">1h30min"
+		return fmt.Sprintf("%dh%dmin", minutes/60, minutes%60)
 	}
 }
 
+// Slot returns the label of the band duration belongs to. A zero DurationSlotter uses DefaultDurationBounds.
+func (s DurationSlotter) Slot(duration time.Duration) string {
+	if duration == 0 {
+		return "unknown"
+	}
+	bounds := s.bounds
+	if len(bounds) == 0 {
+		bounds = DefaultDurationBounds
+	}
+	for _, bound := range bounds {
+		if duration < bound {
+			return "<" + formatDurationLabel(bound)
+		}
+	}
+	return ">" + formatDurationLabel(bounds[len(bounds)-1])
+}
+
+// Minutes returns a Slotter for durations given in minutes.
+func (s DurationSlotter) Minutes() Slotter {
+	return SlotterFunc(func(minutes float64) string {
+		return s.Slot(time.Duration(math.Round(minutes * float64(time.Minute))))
+	})
+}
+
+var defaultDurationSlotter = NewDurationSlotter()
+
+func DurationToSlot(duration time.Duration) string {
+	return defaultDurationSlotter.Slot(duration)
+}
+
+// MaxDepthToSlot buckets the max depth of a dive. Zero and negative depths are "unknown".
 func MaxDepthToSlot(depth float64) string {
 	switch {
-	case depth == 0:
+	case depth <= 0:
 		return "unknown"
 	case depth < 19:
 		return "P1"
@@ -45,9 +112,11 @@ func MaxDepthToSlot(depth float64) string {
 		return "hypo tmx"
 	}
 }
+
+// MeanDepthToSlot buckets the mean depth of a dive. Zero and negative depths are "unknown".
 func MeanDepthToSlot(depth float64) string {
 	switch {
-	case depth == 0:
+	case depth <= 0:
 		return "unknown"
 	case depth < 10:
 		return "<10m"
@@ -66,19 +135,254 @@ func MeanDepthToSlot(depth float64) string {
 	}
 }
 
+// DefaultTemperatureBounds are the upper bounds (°C) of the temperature bands used by TemperatureToSlot.
+var DefaultTemperatureBounds = []float64{0, 5, 10, 15, 20}
+
+// DefaultFahrenheitBounds are the upper bounds (°F) of the temperature bands used by NewFahrenheitTemperatureSlotter.
+var DefaultFahrenheitBounds = []float64{40, 50, 60, 70, 80}
+
+// TemperatureSlotter buckets temperatures into bands with configurable upper bounds. Bands are half-open:
+// a temperature equal to a bound belongs to the next band, so exactly 0.0 is "<5c" with the default bounds.
+type TemperatureSlotter struct {
+	bounds     []float64
+	fahrenheit bool // Bounds and labels are in °F; temperatures are still given in °C
+}
+
+// NewTemperatureSlotter returns a slotter with the given upper bounds in °C, e.g. -4, -2, 0, 5.
+// Bounds may be given in any order. Without bounds, DefaultTemperatureBounds are used.
+func NewTemperatureSlotter(bounds ...float64) TemperatureSlotter {
+	if len(bounds) == 0 {
+		bounds = DefaultTemperatureBounds
+	}
+	return TemperatureSlotter{bounds: sortedBounds(bounds)}
+}
+
+// NewFahrenheitTemperatureSlotter returns a slotter with upper bounds and labels in °F, such as "<50F".
+// Temperatures passed to Slot are still in °C. Without bounds, DefaultFahrenheitBounds are used.
+func NewFahrenheitTemperatureSlotter(bounds ...float64) TemperatureSlotter {
+	if len(bounds) == 0 {
+		bounds = DefaultFahrenheitBounds
+	}
+	return TemperatureSlotter{bounds: sortedBounds(bounds), fahrenheit: true}
+}
+
+func sortedBounds(bounds []float64) []float64 {
+	sorted := append([]float64(nil), bounds...)
+	sort.Float64s(sorted)
+	return sorted
+}
+
+// Slot returns the label of the band temperature, given in °C, belongs to. A zero TemperatureSlotter
+// uses the default bounds.
+func (s TemperatureSlotter) Slot(temperature float64) string {
+	bounds := s.bounds
+	if len(bounds) == 0 {
+		bounds = DefaultTemperatureBounds
+		if s.fahrenheit {
+			bounds = DefaultFahrenheitBounds
+		}
+	}
+	unit := "c"
+	if s.fahrenheit {
+		temperature = CelsiusToFahrenheit(temperature)
+		unit = "F"
+	}
+	for _, bound := range bounds {
+		if temperature < bound {
+			return fmt.Sprintf("<%g%s", bound, unit)
+		}
+	}
+	return fmt.Sprintf(">%g%s", bounds[len(bounds)-1], unit)
+}
+
+var defaultTemperatureSlotter = NewTemperatureSlotter()
+
 func TemperatureToSlot(temperature float64) string {
+	return defaultTemperatureSlotter.Slot(temperature)
+}
+
+func EADToSlot(ead float64) string {
 	switch {
-	case temperature < 0:
-		return "<0c"
-	case temperature < 5:
-		return "<5c"
-	case temperature < 10:
-		return "<10c"
-	case temperature < 15:
-		return "<15c"
-	case temperature < 20:
-		return "<20c"
+	case ead < 10:
+		return "EAD <10m"
+	case ead < 20:
+		return "EAD <20m"
+	case ead < 30:
+		return "EAD <30m"
 	default:
-		return ">20c"
+		return "EAD >30m"
+	}
+}
+
+// AscentRateToSlot buckets ascent rates in meters per minute. 9 m/min is the most common recommendation
+// for the maximum ascent rate.
+func AscentRateToSlot(rate float64) string {
+	switch {
+	case rate < 3:
+		return "<3m/min"
+	case rate < 6:
+		return "<6m/min"
+	case rate < 9:
+		return "<9m/min"
+	case rate < 12:
+		return "<12m/min"
+	default:
+		return ">12m/min"
+	}
+}
+
+// WeightToSlot buckets the total weight of a dive in kilograms.
+func WeightToSlot(weight float64) string {
+	switch {
+	case weight == 0:
+		return "no weights"
+	case weight < 4:
+		return "<4kg"
+	case weight < 8:
+		return "<8kg"
+	case weight < 12:
+		return "<12kg"
+	case weight < 16:
+		return "<16kg"
+	default:
+		return ">16kg"
+	}
+}
+
+func DepthRatioToSlot(ratio float64) string {
+	switch {
+	case ratio == 0:
+		return "unknown"
+	case ratio < 0.5:
+		return "multilevel <0.5"
+	case ratio <= 0.8:
+		return "mixed 0.5-0.8"
+	default:
+		return "square >0.8"
+	}
+}
+
+// SurfaceIntervalToSlot buckets the surface interval before a dive. The first dive of the day has its own slot.
+func SurfaceIntervalToSlot(interval time.Duration) string {
+	switch {
+	case interval == FirstDiveOfDay:
+		return "first of day"
+	case interval < 30*time.Minute:
+		return "<30min"
+	case interval < 60*time.Minute:
+		return "30-60min"
+	case interval < 2*time.Hour:
+		return "1-2h"
+	default:
+		return ">2h"
+	}
+}
+
+// SacToSlot buckets the surface air consumption of a dive in l/min. It is used by the SAC statistic,
+// which counts dives in these bands; SAC logged in cuft/min is converted to l/min when parsed, so both
+// units share the bands.
+func SacToSlot(sac float64) string {
+	switch {
+	case sac == 0:
+		return "unknown"
+	case sac < 10:
+		return "<10l/min"
+	case sac < 15:
+		return "10-15l/min"
+	case sac < 20:
+		return "15-20l/min"
+	case sac < 25:
+		return "20-25l/min"
+	default:
+		return ">25l/min"
+	}
+}
+
+// ImperialSacToSlot buckets SAC given in l/min into bands labelled in cuft/min.
+func ImperialSacToSlot(sac float64) string {
+	cuft := LitersToCuft(sac)
+	switch {
+	case sac == 0:
+		return "unknown"
+	case cuft < 0.4:
+		return "<0.4cuft/min"
+	case cuft < 0.5:
+		return "0.4-0.5cuft/min"
+	case cuft < 0.7:
+		return "0.5-0.7cuft/min"
+	case cuft < 0.9:
+		return "0.7-0.9cuft/min"
+	default:
+		return ">0.9cuft/min"
+	}
+}
+
+// NumberToSlot buckets value into bands of width step, labelled such as "3.5-4". Non-positive steps
+// put every value to a band of its own.
+func NumberToSlot(value, step float64) string {
+	if step <= 0 {
+		return fmt.Sprintf("%g", value)
+	}
+	// Rounding hides floating point noise in labels, e.g. with a step of 0.1.
+	round := func(x float64) float64 { return math.Round(x*1e6) / 1e6 }
+	lower := math.Floor(value/step) * step
+	return fmt.Sprintf("%g-%g", round(lower), round(lower+step))
+}
+
+// Band is a labelled slot for values below UpperBound. A nil UpperBound catches every value above the
+// previous band and is only allowed for the last band.
+type Band struct {
+	UpperBound *float64 `json:"upperBound"`
+	Label      string   `json:"label"`
+}
+
+// BandSlotter buckets values into user defined bands.
+type BandSlotter struct {
+	bands                []Band
+	unknownAtOrBelowZero bool
+}
+
+// NewBandSlotter returns a slotter for bands given in increasing order of UpperBound. Values above the
+// last bound are labelled such as ">50" unless the last band has no upper bound.
+func NewBandSlotter(bands []Band) (BandSlotter, error) {
+	if len(bands) == 0 {
+		return BandSlotter{}, fmt.Errorf("no bands")
+	}
+	var previous *float64
+	for i, band := range bands {
+		if band.Label == "" {
+			return BandSlotter{}, fmt.Errorf("band %d has no label", i+1)
+		}
+		if band.UpperBound == nil {
+			if i != len(bands)-1 {
+				return BandSlotter{}, fmt.Errorf("band %q has no upper bound but is not the last band", band.Label)
+			}
+			continue
+		}
+		if previous != nil && *band.UpperBound <= *previous {
+			return BandSlotter{}, fmt.Errorf("upper bound of band %q is not larger than the previous bound", band.Label)
+		}
+		previous = band.UpperBound
+	}
+	return BandSlotter{bands: append([]Band(nil), bands...)}, nil
+}
+
+// UnknownAtOrBelowZero returns a copy of the slotter that labels zero and negative values "unknown", like
+// the built-in slotters for depths, durations and SAC, where zero means the value is missing.
+func (s BandSlotter) UnknownAtOrBelowZero() BandSlotter {
+	s.unknownAtOrBelowZero = true
+	return s
+}
+
+// Slot returns the label of the first band value is below.
+func (s BandSlotter) Slot(value float64) string {
+	if s.unknownAtOrBelowZero && value <= 0 {
+		return "unknown"
+	}
+	for _, band := range s.bands {
+		if band.UpperBound == nil || value < *band.UpperBound {
+			return band.Label
+		}
 	}
+	return fmt.Sprintf(">%g", *s.bands[len(s.bands)-1].UpperBound)
 }
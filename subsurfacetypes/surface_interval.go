@@ -0,0 +1,36 @@
+package subsurfacetypes
+
+import (
+	"sort"
+	"time"
+)
+
+// FirstDiveOfDay is the surface interval of a dive without an earlier dive on the same day.
+const FirstDiveOfDay time.Duration = -1
+
+// SurfaceIntervals returns the time from the end of the previous dive on the same day to the start of
+// each dive, keyed by dive start time (see Dive.DateTime). Invalid dives and dives without a date are
+// left out. Overlapping dives get a zero interval.
+func SurfaceIntervals(dives []Dive) map[time.Time]time.Duration {
+	var dated []*Dive
+	for i := range dives {
+		if !dives[i].IsInvalid() && dives[i].HasDate() {
+			dated = append(dated, &dives[i])
+		}
+	}
+	sort.Slice(dated, func(i, j int) bool { return dated[i].DateTime().Before(dated[j].DateTime()) })
+	intervals := make(map[time.Time]time.Duration, len(dated))
+	for i, dive := range dated {
+		if i == 0 || !dated[i-1].Date.Value.Equal(dive.Date.Value) {
+			intervals[dive.DateTime()] = FirstDiveOfDay
+			continue
+		}
+		previousEnd := dated[i-1].DateTime().Add(dated[i-1].Duration())
+		interval := dive.DateTime().Sub(previousEnd)
+		if interval < 0 {
+			interval = 0
+		}
+		intervals[dive.DateTime()] = interval
+	}
+	return intervals
+}
@@ -24,13 +24,23 @@ type Settings struct {
 	DiveComputerID []DiveComputerID `xml:"divecomputerid"`
 }
 
+// MarshalXML leaves out settings without any dive computers.
+func (s Settings) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	if len(s.DiveComputerID) == 0 {
+		return nil
+	}
+	type plain Settings
+	p := plain(s)
+	return e.EncodeElement(&p, start)
+}
+
 // DiveComputerID is per-log information about a specific dive computer
 type DiveComputerID struct {
 	XMLName  xml.Name `xml:"divecomputerid"`
 	Model    string   `xml:"model,attr"`
 	DeviceID string   `xml:"deviceid,attr"`
-	Serial   string   `xml:"serial,attr"`
-	Firmware string   `xml:"firmware,attr"`
+	Serial   string   `xml:"serial,attr,omitempty"`
+	Firmware string   `xml:"firmware,attr,omitempty"`
 }
 
 // Divesites holds generic information about each divesite
@@ -44,9 +54,9 @@ type Divesite struct {
 	XMLName     xml.Name      `xml:"site"`
 	UUID        string        `xml:"uuid,attr"`
 	Name        string        `xml:"name,attr"`
-	GPS         string        `xml:"gps,attr"`
-	Description string        `xml:"description,attr"`
-	Notes       string        `xml:"notes"`
+	GPS         string        `xml:"gps,attr,omitempty"`
+	Description string        `xml:"description,attr,omitempty"`
+	Notes       string        `xml:"notes,omitempty"`
 	Geo         []DivesiteGEO `xml:"geo"`
 }
 
@@ -69,46 +79,81 @@ func (d Dives) String() string {
 	return fmt.Sprintf("Dives (%v, trips %v)", len(d.Dives), len(d.Trips))
 }
 
+// Layouts accepted for dive times and dates. The first one is used when writing values that were not parsed.
+var (
+	timeLayouts = []string{"15:04:05", "15:04"}
+	dateLayouts = []string{"2006-01-02", "2006/01/02"}
+)
+
+// parseWithLayouts parses value with the first of layouts that accepts it, and returns the layout used.
+func parseWithLayouts(value string, layouts []string) (time.Time, string, error) {
+	var err error
+	for _, layout := range layouts {
+		var parsedValue time.Time
+		parsedValue, err = time.Parse(layout, strings.TrimSpace(value))
+		if err == nil {
+			return parsedValue, layout, nil
+		}
+	}
+	return time.Time{}, "", err
+}
+
 // SubsurfaceTime holds parsed time information
 type SubsurfaceTime struct {
-	Value time.Time
+	Value  time.Time
+	Layout string // Layout the value was parsed with, used when writing it back
 }
 
-// UnmarshalXMLAttr Parses XML attribute to time
+// UnmarshalXMLAttr Parses XML attribute to time. Times with and without seconds are accepted.
 func (t *SubsurfaceTime) UnmarshalXMLAttr(attr xml.Attr) error {
-	const timeFormat = "15:04:05"
-	parsedValue, err := time.Parse(timeFormat, attr.Value)
+	parsedValue, layout, err := parseWithLayouts(attr.Value, timeLayouts)
 	if err != nil {
 		return err
 	}
-	*t = SubsurfaceTime{parsedValue}
+	*t = SubsurfaceTime{parsedValue, layout}
 	return nil
 }
 
-// MarshalXMLAttr outputs parsed time object to a string
+// MarshalXMLAttr outputs parsed time object to a string in the layout it was parsed with. Times that
+// were never set are omitted.
 func (t *SubsurfaceTime) MarshalXMLAttr(name xml.Name) (xml.Attr, error) {
-	return xml.Attr{Name: name, Value: t.Value.Format("15:04:05")}, nil
+	if t.Layout == "" && t.Value.IsZero() {
+		return xml.Attr{}, nil
+	}
+	layout := t.Layout
+	if layout == "" {
+		layout = timeLayouts[0]
+	}
+	return xml.Attr{Name: name, Value: t.Value.Format(layout)}, nil
 }
 
 // SubsurfaceDate holds parsed date object
 type SubsurfaceDate struct {
-	Value time.Time
+	Value  time.Time
+	Layout string // Layout the value was parsed with, used when writing it back
 }
 
 // UnmarshalXMLAttr Parses XML attribute to date
 func (t *SubsurfaceDate) UnmarshalXMLAttr(attr xml.Attr) error {
-	const dateFormat = "2006-01-02"
-	parsedValue, err := time.Parse(dateFormat, attr.Value)
+	parsedValue, layout, err := parseWithLayouts(attr.Value, dateLayouts)
 	if err != nil {
 		return err
 	}
-	*t = SubsurfaceDate{parsedValue}
+	*t = SubsurfaceDate{parsedValue, layout}
 	return nil
 }
 
-// MarshalXMLAttr formats parsed date back to string
+// MarshalXMLAttr formats parsed date back to string in the layout it was parsed with. Dives without a
+// date omit the attribute.
 func (t *SubsurfaceDate) MarshalXMLAttr(name xml.Name) (xml.Attr, error) {
-	return xml.Attr{Name: name, Value: t.Value.Format("2006-01-02")}, nil
+	if t.Value.IsZero() {
+		return xml.Attr{}, nil
+	}
+	layout := t.Layout
+	if layout == "" {
+		layout = dateLayouts[0]
+	}
+	return xml.Attr{Name: name, Value: t.Value.Format(layout)}, nil
 }
 
 func (t SubsurfaceTime) Duration() time.Duration {
@@ -117,38 +162,41 @@ func (t SubsurfaceTime) Duration() time.Duration {
 
 // Trip is a collection of dives.
 type Trip struct {
-	Date     string `xml:"date,attr"`
-	Time     string `xml:"time,attr"`
-	Location string `xml:"location,attr"`
+	Date     string `xml:"date,attr,omitempty"`
+	Time     string `xml:"time,attr,omitempty"`
+	Location string `xml:"location,attr,omitempty"`
 	Dives    []Dive `xml:"dive"`
-	Notes    string `xml:"notes"`
+	Notes    string `xml:"notes,omitempty"`
 }
 
 // Dive has information about a single dive.
 type Dive struct {
 	XMLName         xml.Name              `xml:"dive"`
 	TripFlag        string                `xml:"tripflag,attr,omitempty"`
-	Divemaster      string                `xml:"divemaster"`
+	Divemaster      string                `xml:"divemaster,omitempty"`
 	Number          string                `xml:"number,attr"`
 	Tags            Tags                  `xml:"tags,attr,omitempty"`
 	DiveSiteID      string                `xml:"divesiteid,attr,omitempty"`
 	Date            SubsurfaceDate        `xml:"date,attr,omitempty"`
 	Time            SubsurfaceTime        `xml:"time,attr,omitempty"`
 	RawDuration     string                `xml:"duration,attr,omitempty"`
-	Buddy           string                `xml:"buddy"`
+	Buddy           string                `xml:"buddy,omitempty"`
 	Cylinders       []Cylinder            `xml:"cylinder"`
 	Invalid         string                `xml:"invalid,attr,omitempty"`
 	DiveTemperature ManualDiveTemperature `xml:"divetemperature"`
-	DiveComputer    DiveComputer          `xml:"divecomputer"`
+	ManualDepth     *DiveDepth            `xml:"depth,omitempty"`
+	DiveComputers   []DiveComputer        `xml:"divecomputer"`
 	Rating          string                `xml:"rating,attr,omitempty"`
 	CNS             string                `xml:"cns,attr,omitempty"`
 	SAC             string                `xml:"sac,attr,omitempty"`
-	Notes           string                `xml:"notes"`
+	Notes           string                `xml:"notes,omitempty"`
 	OTU             string                `xml:"otu,attr,omitempty"`
 	Visibility      string                `xml:"visibility,attr,omitempty"`
 	Current         string                `xml:"current,attr,omitempty"`
-	Suit            string                `xml:"suit"`
+	Suit            string                `xml:"suit,omitempty"`
 	WeightSystem    []WeightSystem        `xml:"weightsystem"`
+	TripLocation    string                `xml:"-"` // Location of the trip the dive belongs to; set by the caller
+	TripNumber      int                   `xml:"-"` // 1-based index of the trip the dive belongs to, 0 outside trips; set by the caller
 }
 
 // ManualDiveTemperature holds manually added dive temperature information
@@ -158,6 +206,16 @@ type ManualDiveTemperature struct {
 	Air     string   `xml:"air,attr,omitempty"`
 }
 
+// MarshalXML leaves out the element if no temperature was entered.
+func (t ManualDiveTemperature) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	if t.Water == "" && t.Air == "" {
+		return nil
+	}
+	type plain ManualDiveTemperature
+	p := plain(t)
+	return e.EncodeElement(&p, start)
+}
+
 // WeightSystem has weight system information (weights, where those were deployed to)
 type WeightSystem struct {
 	XMLName     xml.Name `xml:"weightsystem"`
@@ -176,14 +234,71 @@ func (t *Tags) UnmarshalXMLAttr(attr xml.Attr) error {
 	return nil
 }
 
+// MarshalXMLAttr joins the tags back to a single attribute. Dives without tags omit the attribute.
 func (t *Tags) MarshalXMLAttr(name xml.Name) (xml.Attr, error) {
+	if len(t.Value) == 0 {
+		return xml.Attr{}, nil
+	}
 	return xml.Attr{Name: name, Value: strings.Join(t.Value, ", ")}, nil
 }
 
+// RatingStars returns the rating of the dive, 0 to 5 stars. Unrated dives and invalid values return 0.
+func (d Dive) RatingStars() int {
+	stars, err := strconv.Atoi(strings.TrimSpace(d.Rating))
+	if err != nil || stars < 0 || stars > 5 {
+		return 0
+	}
+	return stars
+}
+
+// NumberInt returns the dive number as an integer.
+func (d Dive) NumberInt() (int, error) {
+	return strconv.Atoi(strings.TrimSpace(d.Number))
+}
+
+// Identity returns a key that identifies the same dive in different parts of a divelog: the dive number,
+// or the start time, or the device and dive ids of the dive computer. Dives without any of these return
+// an empty string.
+func (d Dive) Identity() string {
+	if number := strings.TrimSpace(d.Number); number != "" {
+		return "number " + number
+	}
+	if d.HasDate() {
+		return "time " + d.DateTime().Format("2006-01-02 15:04:05")
+	}
+	diveComputer := d.PrimaryComputer()
+	if diveComputer.DeviceID != "" && diveComputer.DiveID != "" {
+		return "divecomputer " + strings.ToLower(diveComputer.DeviceID+" "+diveComputer.DiveID)
+	}
+	return ""
+}
+
+// NoTripFlag is the tripflag of dives that the user has removed from a trip.
+const NoTripFlag = "NOTRIP"
+
+// NotInTrip returns true if the dive is explicitly marked as not belonging to a trip.
+func (d Dive) NotInTrip() bool {
+	return strings.EqualFold(strings.TrimSpace(d.TripFlag), NoTripFlag)
+}
+
 func (d Dive) IsInvalid() bool {
 	return d.Invalid == "1"
 }
 
+// PrimaryComputer returns the first dive computer with samples, or the first dive computer if none of them
+// has samples. Dives without dive computers return an empty DiveComputer.
+func (d *Dive) PrimaryComputer() DiveComputer {
+	for _, diveComputer := range d.DiveComputers {
+		if len(diveComputer.Samples) > 0 {
+			return diveComputer
+		}
+	}
+	if len(d.DiveComputers) > 0 {
+		return d.DiveComputers[0]
+	}
+	return DiveComputer{}
+}
+
 // DiveComputer holds information imported from a dive computer.
 type DiveComputer struct {
 	XMLName        xml.Name        `xml:"divecomputer"`
@@ -208,6 +323,16 @@ type WaterDetails struct {
 	Salinity string   `xml:"salinity,attr,omitempty"`
 }
 
+// MarshalXML leaves out the element if the salinity is not known.
+func (w WaterDetails) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	if w.Salinity == "" {
+		return nil
+	}
+	type plain WaterDetails
+	p := plain(w)
+	return e.EncodeElement(&p, start)
+}
+
 // ExtraData describes any unstructured values provided by the dive computer.
 type ExtraData struct {
 	XMLName xml.Name `xml:"extradata"`
@@ -247,23 +372,40 @@ type Surface struct {
 	Pressure string   `xml:"pressure,attr,omitempty"`
 }
 
+// MarshalXML leaves out the element if the surface pressure is not known.
+func (s Surface) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	if s.Pressure == "" {
+		return nil
+	}
+	type plain Surface
+	p := plain(s)
+	return e.EncodeElement(&p, start)
+}
+
 // DepthReading is a parsed depth reading
 type DepthReading struct {
-	Value float64
+	Value   float64
+	Invalid string // Value in the XML if it could not be parsed
 }
 
+// UnmarshalXMLAttr parses depths such as "15.2 m", "15.2m" or "50 ft" to meters.
+// Negative depths are rejected with a warning and left as zero, i.e. unknown.
 func (d *DepthReading) UnmarshalXMLAttr(attr xml.Attr) error {
-	if !strings.HasSuffix(attr.Value, " m") {
-		fmt.Println("Invalid depth:", attr.Value)
+	val, err := parseDepthMeters(attr.Value)
+	if err != nil || val < 0 {
+		Warnings.Add(DepthWarning, "Invalid depth:", attr.Value)
+		*d = DepthReading{Invalid: attr.Value}
 		return nil
 	}
-	r := strings.Split(attr.Value, " ")
-	val, _ := strconv.ParseFloat(r[0], 64)
-	*d = DepthReading{val}
+	*d = DepthReading{Value: val}
 	return nil
 }
 
+// MarshalXMLAttr writes the depth in meters. Unknown (zero) depths omit the attribute.
 func (d *DepthReading) MarshalXMLAttr(name xml.Name) (xml.Attr, error) {
+	if d.Value == 0 {
+		return xml.Attr{}, nil
+	}
 	return xml.Attr{Name: name, Value: fmt.Sprintf("%f m", d.Value)}, nil
 }
 
@@ -274,10 +416,27 @@ type DiveDepth struct {
 	Mean    DepthReading `xml:"mean,attr"`
 }
 
-// TimeSince returns duration since dive was logged
+// HasDate returns true if the dive has a date.
+func (d *Dive) HasDate() bool {
+	return !d.Date.Value.IsZero()
+}
+
+// DateTime returns the start time of the dive.
+func (d *Dive) DateTime() time.Time {
+	return d.Date.Value.Add(d.Time.Duration())
+}
+
+// TimeSince returns duration since dive was logged. Dives without a date return zero; check HasDate first.
 func (d *Dive) TimeSince() time.Duration {
-	diveDate := d.Date.Value.Add(d.Time.Duration())
-	return time.Since(diveDate)
+	return d.TimeSinceAt(time.Now())
+}
+
+// TimeSinceAt returns duration from the dive to now. Dives without a date return zero; check HasDate first.
+func (d *Dive) TimeSinceAt(now time.Time) time.Duration {
+	if !d.HasDate() {
+		return 0
+	}
+	return now.Sub(d.DateTime())
 }
 
 // BuddyList returns a list of buddies (or empty list)
@@ -289,23 +448,19 @@ func (d *Dive) BuddyList() []string {
 	return splitBuddies
 }
 
-// Duration returns parsed dive duration
+// Duration returns parsed dive duration, or zero if the duration is missing or invalid.
 func (d *Dive) Duration() time.Duration {
-	if strings.HasSuffix(d.RawDuration, " min") {
-		a := strings.Split(d.RawDuration, " ")
-		b := strings.Split(a[0], ":")
-		secondsInt, err := strconv.Atoi(b[1])
-		var secondsFraction float64
-		if err == nil {
-			secondsFraction = float64(secondsInt) / 60.0
-		}
-		minutesInt, _ := strconv.Atoi(b[0])
-		durationFraction := float64(minutesInt) + secondsFraction
-		duration, _ := time.ParseDuration(fmt.Sprintf("%.5f", durationFraction) + "m")
-		return duration
+	duration, _ := d.ParseDuration()
+	return duration
+}
+
+// ParseDuration parses the dive duration. ErrMissingValue is returned for dives without a duration.
+func (d *Dive) ParseDuration() (time.Duration, error) {
+	duration, err := parseMinutesSeconds(d.RawDuration)
+	if err != nil && err != ErrMissingValue {
+		return 0, fmt.Errorf("invalid duration %q: %w", d.RawDuration, err)
 	}
-	zeroDuration, _ := time.ParseDuration("0s")
-	return zeroDuration
+	return duration, err
 }
 
 // Cylinder has information about cylinders used on the dive.
@@ -330,26 +485,123 @@ type DiveTemperature struct {
 
 // Temperature holds temperature information, including whether temperature was valid (in order to avoid outputting 0 C).
 type Temperature struct {
-	Value float64
-	Valid bool
+	Value   float64
+	Valid   bool
+	Invalid string // Value in the XML if it could not be parsed
+}
+
+// parseTemperature parses temperatures such as "12.5 C" or "24C". Only celsius is supported.
+func parseTemperature(value string) (Temperature, bool) {
+	convertedTemperature, unit, err := splitValueAndUnit(value)
+	if err != nil || unit != "C" {
+		return Temperature{}, false
+	}
+	return Temperature{Value: convertedTemperature, Valid: true}, true
 }
 
 // UnmarshalXMLAttr parses temperature information. Only celsius is supported.
 func (t *Temperature) UnmarshalXMLAttr(attr xml.Attr) error {
-	if !strings.HasSuffix(attr.Value, " C") {
-		fmt.Println("Invalid water temperature:", attr.Value)
+	temperature, ok := parseTemperature(attr.Value)
+	if !ok {
+		Warnings.Add(TemperatureWarning, "Invalid temperature:", attr.Value)
+		*t = Temperature{Invalid: attr.Value}
 		return nil
 	}
-	r := strings.Split(attr.Value, " ")
-	convertedTemperature, _ := strconv.ParseFloat(r[0], 64)
-	*t = Temperature{convertedTemperature, true}
+	*t = temperature
 	return nil
 }
 
-// MarshalXMLAttr outputs temperature information back to XML. Only celsius is supported.
+// MarshalXMLAttr outputs temperature information back to XML. Only celsius is supported. Unknown
+// temperatures are left out.
 func (t *Temperature) MarshalXMLAttr(name xml.Name) (xml.Attr, error) {
 	if t.Valid {
 		return xml.Attr{Name: name, Value: fmt.Sprintf("%f C", t.Value)}, nil
 	}
-	return xml.Attr{Name: name, Value: ""}, nil
+	return xml.Attr{}, nil
+}
+
+// AirTemperature returns the air temperature recorded by the dive computer, falling back to the manually entered value.
+func (d *Dive) AirTemperature() Temperature {
+	if air := d.PrimaryComputer().Temperature.Air; air.Valid {
+		return air
+	}
+	temperature, _ := parseTemperature(d.DiveTemperature.Air)
+	return temperature
+}
+
+// WaterTemperature returns the water temperature recorded by the dive computer, falling back to the manually entered value.
+func (d *Dive) WaterTemperature() Temperature {
+	if water := d.PrimaryComputer().Temperature.Water; water.Valid {
+		return water
+	}
+	temperature, _ := parseTemperature(d.DiveTemperature.Water)
+	return temperature
+}
+
+// ParseProblems describes the depth and temperature values of the dive that could not be parsed. Those
+// values are treated as unknown in the statistics.
+func (d *Dive) ParseProblems() []string {
+	var problems []string
+	addDepth := func(name string, depth DepthReading) {
+		if depth.Invalid != "" {
+			problems = append(problems, fmt.Sprintf("invalid %s depth %q", name, depth.Invalid))
+		}
+	}
+	addTemperature := func(name string, temperature Temperature) {
+		if temperature.Invalid != "" {
+			problems = append(problems, fmt.Sprintf("invalid %s temperature %q", name, temperature.Invalid))
+		}
+	}
+	for _, diveComputer := range d.DiveComputers {
+		addDepth("max", diveComputer.Depth.Max)
+		addDepth("mean", diveComputer.Depth.Mean)
+		addTemperature("water", diveComputer.Temperature.Water)
+		addTemperature("air", diveComputer.Temperature.Air)
+	}
+	if d.ManualDepth != nil {
+		addDepth("manual max", d.ManualDepth.Max)
+		addDepth("manual mean", d.ManualDepth.Mean)
+	}
+	if water := d.DiveTemperature.Water; water != "" {
+		if _, ok := parseTemperature(water); !ok {
+			problems = append(problems, fmt.Sprintf("invalid manual water temperature %q", water))
+		}
+	}
+	if air := d.DiveTemperature.Air; air != "" {
+		if _, ok := parseTemperature(air); !ok {
+			problems = append(problems, fmt.Sprintf("invalid manual air temperature %q", air))
+		}
+	}
+	return problems
+}
+
+// Depth returns the depths recorded by the dive computer. Manually entered dives without a dive computer
+// fall back to the depths entered on the dive itself.
+func (d *Dive) Depth() DiveDepth {
+	depth := d.PrimaryComputer().Depth
+	if d.ManualDepth == nil {
+		return depth
+	}
+	if depth.Max.Value <= 0 {
+		depth.Max = d.ManualDepth.Max
+	}
+	if depth.Mean.Value <= 0 {
+		depth.Mean = d.ManualDepth.Mean
+	}
+	return depth
+}
+
+// DepthRatio returns mean depth divided by max depth. Values close to one indicate a square profile and small
+// values a multilevel dive. The ratio is only valid when both depths are known.
+func (d *Dive) DepthRatio() (float64, bool) {
+	depth := d.Depth()
+	if depth.Mean.Value <= 0 || depth.Max.Value <= 0 {
+		return 0, false
+	}
+	return depth.Mean.Value / depth.Max.Value, true
+}
+
+// SACLitersPerMinute returns the surface air consumption of the dive in l/min. Values in cuft/min are converted.
+func (d *Dive) SACLitersPerMinute() (float64, error) {
+	return parseRateLitersPerMinute(d.SAC)
 }
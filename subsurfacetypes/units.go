@@ -0,0 +1,171 @@
+package subsurfacetypes
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	psiInBar     = 0.0689476
+	feetInMeters = 0.3048
+	cuftInLiters = 28.3168
+	lbsInKg      = 0.453592
+)
+
+// ErrMissingValue is returned when an optional attribute needed for a computation is empty.
+var ErrMissingValue = errors.New("missing value")
+
+// splitValueAndUnit splits values such as "12.0 l" to a number and a unit.
+func splitValueAndUnit(value string) (float64, string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, "", ErrMissingValue
+	}
+	i := strings.IndexFunc(value, func(r rune) bool {
+		return !(r >= '0' && r <= '9' || r == '.' || r == '-' || r == '+')
+	})
+	if i == -1 {
+		i = len(value)
+	}
+	number, err := strconv.ParseFloat(value[:i], 64)
+	if err != nil {
+		return 0, "", fmt.Errorf("invalid value %q: %w", value, err)
+	}
+	return number, strings.TrimSpace(value[i:]), nil
+}
+
+// parsePressureBar parses a pressure in bar or psi and returns it in bar.
+func parsePressureBar(value string) (float64, error) {
+	number, unit, err := splitValueAndUnit(value)
+	if err != nil {
+		return 0, err
+	}
+	switch strings.ToLower(unit) {
+	case "bar":
+		return number, nil
+	case "psi":
+		return number * psiInBar, nil
+	default:
+		return 0, fmt.Errorf("unsupported pressure unit in %q", value)
+	}
+}
+
+// parseVolumeLiters parses a volume in liters, such as "12.0 l" or "12 L". Subsurface always writes cylinder
+// sizes as water volume in liters. Sizes in cuft are not supported, because they give the volume of gas at
+// the working pressure and cannot be converted to water volume without it.
+func parseVolumeLiters(value string) (float64, error) {
+	number, unit, err := splitValueAndUnit(value)
+	if err != nil {
+		return 0, err
+	}
+	switch strings.ToLower(unit) {
+	case "l":
+		return number, nil
+	default:
+		return 0, fmt.Errorf("unsupported volume unit in %q", value)
+	}
+}
+
+// parseDepthMeters parses a depth in meters or feet and returns it in meters.
+func parseDepthMeters(value string) (float64, error) {
+	number, unit, err := splitValueAndUnit(value)
+	if err != nil {
+		return 0, err
+	}
+	switch strings.ToLower(unit) {
+	case "m":
+		return number, nil
+	case "ft":
+		return number * feetInMeters, nil
+	default:
+		return 0, fmt.Errorf("unsupported depth unit in %q", value)
+	}
+}
+
+// parseMinutesSeconds parses times such as "45:30 min", "45:30", "45 min" or "45.5 min". A value without
+// seconds is minutes, which may have decimals. Seconds must be below 60: Subsurface always writes them
+// that way, so a larger value is most likely a typo and is rejected rather than guessed.
+func parseMinutesSeconds(value string) (time.Duration, error) {
+	value = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(value), "min"))
+	if value == "" {
+		return 0, ErrMissingValue
+	}
+	parts := strings.Split(value, ":")
+	if len(parts) > 2 {
+		return 0, fmt.Errorf("invalid time %q", value)
+	}
+	if len(parts) == 1 {
+		minutes, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid time %q: %w", value, err)
+		}
+		// The comparisons also reject NaN, infinities and durations that overflow.
+		if !(minutes >= 0) || minutes > float64(math.MaxInt64)/float64(time.Minute) {
+			return 0, fmt.Errorf("invalid time %q", value)
+		}
+		return time.Duration(minutes * float64(time.Minute)).Round(time.Second), nil
+	}
+	minutes, err := strconv.Atoi(strings.TrimSpace(parts[0]))
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q: %w", value, err)
+	}
+	seconds, err := strconv.Atoi(strings.TrimSpace(parts[1]))
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q: %w", value, err)
+	}
+	if minutes < 0 || seconds < 0 || seconds >= 60 {
+		return 0, fmt.Errorf("invalid time %q", value)
+	}
+	return time.Duration(minutes)*time.Minute + time.Duration(seconds)*time.Second, nil
+}
+
+// parseRateLitersPerMinute parses a gas consumption rate in l/min or cuft/min and returns it in l/min.
+func parseRateLitersPerMinute(value string) (float64, error) {
+	number, unit, err := splitValueAndUnit(value)
+	if err != nil {
+		return 0, err
+	}
+	switch strings.ToLower(unit) {
+	case "l/min":
+		return number, nil
+	case "cuft/min":
+		return number * cuftInLiters, nil
+	default:
+		return 0, fmt.Errorf("unsupported consumption unit in %q", value)
+	}
+}
+
+// MetersToFeet converts a depth or distance in meters to feet.
+func MetersToFeet(meters float64) float64 {
+	return meters / feetInMeters
+}
+
+// CelsiusToFahrenheit converts a temperature in °C to °F.
+func CelsiusToFahrenheit(celsius float64) float64 {
+	return celsius*9/5 + 32
+}
+
+// LitersToCuft converts a gas volume in liters to cubic feet.
+func LitersToCuft(liters float64) float64 {
+	return liters / cuftInLiters
+}
+
+// parseWeightKg parses weights such as "6.0 kg" or "14 lbs" and returns kilograms.
+func parseWeightKg(value string) (float64, error) {
+	number, unit, err := splitValueAndUnit(value)
+	if err != nil {
+		return 0, err
+	}
+	switch strings.ToLower(unit) {
+	case "kg":
+		return number, nil
+	case "lb", "lbs":
+		return number * lbsInKg, nil
+	default:
+		return 0, fmt.Errorf("unsupported weight unit in %q", value)
+	}
+}
@@ -0,0 +1,84 @@
+package subsurfacetypes
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Version is a dotted version number, such as "6.0" or "3", as a list of numeric components.
+type Version []int
+
+// DivesiteVersion is the first divelog format version that stores dive sites separately from dives.
+// Older logs only have a free-form location on each dive.
+var DivesiteVersion = Version{3}
+
+// ParseVersion parses a dotted version number. A leading "v" is accepted; anything after the numeric
+// part, such as "-beta", is ignored.
+func ParseVersion(value string) (Version, error) {
+	value = strings.TrimPrefix(strings.TrimSpace(value), "v")
+	if end := strings.IndexFunc(value, func(r rune) bool { return r != '.' && (r < '0' || r > '9') }); end >= 0 {
+		value = value[:end]
+	}
+	if value == "" {
+		return nil, ErrMissingValue
+	}
+	var version Version
+	for _, component := range strings.Split(strings.TrimSuffix(value, "."), ".") {
+		number, err := strconv.Atoi(component)
+		if err != nil {
+			return nil, fmt.Errorf("invalid version %q: %w", value, err)
+		}
+		version = append(version, number)
+	}
+	return version, nil
+}
+
+// Compare returns -1, 0 or 1 when v is older than, equal to or newer than other. Missing components
+// count as zero, so "6" equals "6.0".
+func (v Version) Compare(other Version) int {
+	for i := 0; i < len(v) || i < len(other); i++ {
+		var a, b int
+		if i < len(v) {
+			a = v[i]
+		}
+		if i < len(other) {
+			b = other[i]
+		}
+		switch {
+		case a < b:
+			return -1
+		case a > b:
+			return 1
+		}
+	}
+	return 0
+}
+
+func (v Version) String() string {
+	components := make([]string, len(v))
+	for i, number := range v {
+		components[i] = strconv.Itoa(number)
+	}
+	return strings.Join(components, ".")
+}
+
+// ProgramVersion returns the program that exported the divelog and the parsed format version.
+func (d *Divelog) ProgramVersion() (string, Version, error) {
+	version, err := ParseVersion(d.Version)
+	return d.Program, version, err
+}
+
+// VersionWarnings describes features the divelog format version does not support. Logs without
+// a version are not warned about.
+func (d *Divelog) VersionWarnings() []string {
+	_, version, err := d.ProgramVersion()
+	if err != nil {
+		return nil
+	}
+	var warnings []string
+	if version.Compare(DivesiteVersion) < 0 {
+		warnings = append(warnings, fmt.Sprintf("Divelog format version %s predates dive sites; dive site statistics will be empty", version))
+	}
+	return warnings
+}